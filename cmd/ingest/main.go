@@ -0,0 +1,126 @@
+// Command ingest loads Flower pod logs from files or stdin into the store, handling their events
+// the same way the API does for live pods. It is meant for log bundles of clusters the API cannot reach.
+//
+// Usage:
+//
+//	ingest [-pod name] [-node name] [-component clientapp|serverapp] [-base-time RFC3339] [file ...]
+//
+// Every line is either `kubectl logs --timestamps` output or a plain JSON event. Plain JSON
+// lines need -base-time: line n of a file is stamped with the base time plus n-1 microseconds,
+// so ingesting the same file again with the same base time skips its duplicates. Without files,
+// or for the file "-", stdin is read. The pod, node and component of a file are taken from its
+// name, <component>_<pod>[_<node>].log, unless they are given as flags; a name without
+// underscores is just the pod. The database and blob store are configured with the same
+// variables as the API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/app"
+)
+
+// stdinName selects stdin as a source.
+const stdinName = "-"
+
+func main() {
+	podName := flag.String("pod", "", "pod name of the lines; overrides the file name")
+	nodeName := flag.String("node", "", "node name of the lines; overrides the file name")
+	component := flag.String("component", "", "component of the events, e.g. clientapp or serverapp; overrides the file name")
+	baseTimeFlag := flag.String("base-time", "", "RFC3339 time the lines without a timestamp are stamped from")
+	flag.Parse()
+
+	var baseTime time.Time
+	if *baseTimeFlag != "" {
+		t, err := time.Parse(time.RFC3339Nano, *baseTimeFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -base-time: %v\n", err)
+			os.Exit(2)
+		}
+		baseTime = t
+	}
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{stdinName}
+	}
+
+	var sources []app.IngestSource
+	for _, path := range paths {
+		src, err := openSource(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer closeSource(src)
+
+		if *podName != "" {
+			src.PodName = *podName
+		}
+		if *nodeName != "" {
+			src.NodeName = *nodeName
+		}
+		if *component != "" {
+			src.Component = *component
+		}
+
+		src.BaseTime = baseTime
+
+		if src.Component == "" {
+			fmt.Fprintf(os.Stderr, "%s: no component in the file name, use -component\n", path)
+			os.Exit(2)
+		}
+
+		sources = append(sources, src)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.RunIngest(ctx, sources); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// openSource opens a file, or stdin, and fills in the metadata found in its name.
+func openSource(path string) (app.IngestSource, error) {
+	if path == stdinName {
+		return app.IngestSource{Name: "stdin", Reader: os.Stdin, PodName: "stdin"}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return app.IngestSource{}, err
+	}
+
+	src := app.IngestSource{Name: path, Reader: f}
+
+	// Kubernetes names cannot contain underscores, so they separate the parts.
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(path), ".log"), "_")
+	switch len(parts) {
+	case 2:
+		src.Component, src.PodName = parts[0], parts[1]
+	case 3:
+		src.Component, src.PodName, src.NodeName = parts[0], parts[1], parts[2]
+	default:
+		src.PodName = strings.Join(parts, "_")
+	}
+
+	return src, nil
+}
+
+func closeSource(src app.IngestSource) {
+	if f, ok := src.Reader.(*os.File); ok && f != os.Stdin {
+		f.Close()
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS log_puller_cursors (
+  pod_uid TEXT NOT NULL,
+  container TEXT NOT NULL,
+  namespace VARCHAR(255) NOT NULL,
+  pod_name VARCHAR(255) NOT NULL,
+  last_timestamp TIMESTAMPTZ NOT NULL,
+  last_line_hash TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (pod_uid, container)
+);
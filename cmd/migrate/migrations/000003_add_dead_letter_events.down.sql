@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_dead_letter_events_unresolved;
+DROP TABLE IF EXISTS dead_letter_events;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS dead_letter_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  stage VARCHAR(16) NOT NULL CHECK (stage IN ('parse', 'handle')),
+  raw_line TEXT NOT NULL,
+  pod_name VARCHAR(255) NOT NULL,
+  node_name VARCHAR(255) NOT NULL,
+  component VARCHAR(255) NOT NULL,
+  event VARCHAR(255) NOT NULL DEFAULT '',
+  log_timestamp TIMESTAMPTZ NOT NULL,
+  error TEXT NOT NULL,
+  attempts INT NOT NULL DEFAULT 1,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letter_events_unresolved
+  ON dead_letter_events (created_at)
+  WHERE resolved_at IS NULL;
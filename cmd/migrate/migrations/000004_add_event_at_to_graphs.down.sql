@@ -0,0 +1,3 @@
+ALTER TABLE testing_graph_points DROP COLUMN IF EXISTS event_at;
+ALTER TABLE training_graph_points DROP COLUMN IF EXISTS event_at;
+ALTER TABLE training_graphs DROP COLUMN IF EXISTS event_at;
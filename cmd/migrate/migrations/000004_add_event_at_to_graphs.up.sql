@@ -0,0 +1,13 @@
+-- event_at is the log timestamp of the event that last wrote the row.
+-- Upserts only overwrite a row with data from an event that is not older.
+ALTER TABLE training_graphs ADD COLUMN IF NOT EXISTS event_at TIMESTAMPTZ;
+UPDATE training_graphs SET event_at = created_at WHERE event_at IS NULL;
+ALTER TABLE training_graphs ALTER COLUMN event_at SET NOT NULL;
+
+ALTER TABLE training_graph_points ADD COLUMN IF NOT EXISTS event_at TIMESTAMPTZ;
+UPDATE training_graph_points SET event_at = created_at WHERE event_at IS NULL;
+ALTER TABLE training_graph_points ALTER COLUMN event_at SET NOT NULL;
+
+ALTER TABLE testing_graph_points ADD COLUMN IF NOT EXISTS event_at TIMESTAMPTZ;
+UPDATE testing_graph_points SET event_at = created_at WHERE event_at IS NULL;
+ALTER TABLE testing_graph_points ALTER COLUMN event_at SET NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_client_logs_text_fts;
+DROP INDEX IF EXISTS idx_client_logs_text_trgm;
+DROP INDEX IF EXISTS idx_client_logs_client_id_output_at;
@@ -0,0 +1,13 @@
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+-- Keyset pagination and time ranges over the lines of one client.
+CREATE INDEX IF NOT EXISTS idx_client_logs_client_id_output_at
+  ON client_logs (client_id, client_output_at, id);
+
+-- Substring search (ILIKE '%...%').
+CREATE INDEX IF NOT EXISTS idx_client_logs_text_trgm
+  ON client_logs USING GIN (text gin_trgm_ops);
+
+-- Full-text search.
+CREATE INDEX IF NOT EXISTS idx_client_logs_text_fts
+  ON client_logs USING GIN (to_tsvector('simple', text));
@@ -0,0 +1,11 @@
+-- Rows whose weights only live in the blob store cannot be restored into payload.
+DELETE FROM fl_model_weights WHERE payload IS NULL;
+
+DROP INDEX IF EXISTS idx_fl_model_weights_sha256;
+ALTER TABLE fl_model_weights DROP CONSTRAINT IF EXISTS fl_model_weights_payload_or_blob;
+ALTER TABLE fl_model_weights DROP COLUMN IF EXISTS stored_size;
+ALTER TABLE fl_model_weights DROP COLUMN IF EXISTS raw_size;
+ALTER TABLE fl_model_weights DROP COLUMN IF EXISTS layers;
+ALTER TABLE fl_model_weights DROP COLUMN IF EXISTS dtype;
+ALTER TABLE fl_model_weights DROP COLUMN IF EXISTS sha256;
+ALTER TABLE fl_model_weights ALTER COLUMN payload SET NOT NULL;
@@ -0,0 +1,13 @@
+-- Model weights are stored as blobs keyed by sha256; the row keeps their metadata.
+-- payload is only kept for rows written before this migration.
+ALTER TABLE fl_model_weights ALTER COLUMN payload DROP NOT NULL;
+ALTER TABLE fl_model_weights ADD COLUMN IF NOT EXISTS sha256 TEXT;
+ALTER TABLE fl_model_weights ADD COLUMN IF NOT EXISTS dtype TEXT;
+ALTER TABLE fl_model_weights ADD COLUMN IF NOT EXISTS layers JSONB;
+ALTER TABLE fl_model_weights ADD COLUMN IF NOT EXISTS raw_size BIGINT;
+ALTER TABLE fl_model_weights ADD COLUMN IF NOT EXISTS stored_size BIGINT;
+
+ALTER TABLE fl_model_weights ADD CONSTRAINT fl_model_weights_payload_or_blob
+  CHECK (payload IS NOT NULL OR sha256 IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_fl_model_weights_sha256 ON fl_model_weights (sha256);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uq_client_logs_client_id_output_at_text;
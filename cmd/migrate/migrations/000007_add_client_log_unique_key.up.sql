@@ -0,0 +1,11 @@
+-- A log line is stored once per client and timestamp, so a re-read or re-driven
+-- READLINE event is a no-op. Lines that were already stored twice keep their oldest copy.
+DELETE FROM client_logs a
+  USING client_logs b
+  WHERE a.client_id = b.client_id
+    AND a.client_output_at = b.client_output_at
+    AND a.text = b.text
+    AND (a.created_at, a.id) > (b.created_at, b.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_client_logs_client_id_output_at_text
+  ON client_logs (client_id, client_output_at, md5(text));
@@ -0,0 +1,6 @@
+DELETE FROM dead_letter_events WHERE stage = 'truncated';
+
+ALTER TABLE dead_letter_events DROP CONSTRAINT IF EXISTS dead_letter_events_stage_check;
+
+ALTER TABLE dead_letter_events ADD CONSTRAINT dead_letter_events_stage_check
+  CHECK (stage IN ('parse', 'handle'));
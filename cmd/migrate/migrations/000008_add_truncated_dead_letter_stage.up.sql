@@ -0,0 +1,5 @@
+-- Lines cut by the log reader are recorded as dead letters of their own stage.
+ALTER TABLE dead_letter_events DROP CONSTRAINT IF EXISTS dead_letter_events_stage_check;
+
+ALTER TABLE dead_letter_events ADD CONSTRAINT dead_letter_events_stage_check
+  CHECK (stage IN ('parse', 'handle', 'truncated'));
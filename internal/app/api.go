@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/blob"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/events"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/kubeclient"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+type application struct {
+	config           config
+	store            *store.Storage
+	logger           *zap.SugaredLogger
+	kube             *kubeclient.Set
+	broadcaster      *eventBroadcaster
+	cursors          *cursorTracker
+	registry         *events.Registry
+	readlines        *readlineBatcher
+	ensured          *ensureCache
+	weightsTransfers *weightsTransfers
+	metrics          *pipelineMetrics
+	pipelineHealth   *pipelineHealth
+
+	// handling serializes event handling: the consumer and dead letter re-drives share
+	// the ensure cache, the READLINE batches and the last_log_read markers.
+	handling sync.Mutex
+}
+
+type config struct {
+	addr       string
+	env        string
+	db         dbConfig
+	kubeconfig string
+	podFilter  podFilterConfig
+	logPuller  logPullerConfig
+	readlines  readlineConfig
+	cache      cacheConfig
+	blob       blobConfig
+	weights    weightsTransferConfig
+	shutdown   shutdownConfig
+	health     healthConfig
+}
+
+type dbConfig struct {
+	addr         string
+	maxOpenConns int
+	maxIdleConns int
+	maxIdleTime  string
+}
+
+type podFilterConfig struct {
+	namespace     string
+	labelSelector string
+}
+
+type readlineConfig struct {
+	batchSize   int
+	maxBatchAge string
+}
+
+type blobConfig struct {
+	// backend is "fs" or "s3".
+	backend string
+	fsRoot  string
+	s3      blob.S3Config
+}
+
+type healthConfig struct {
+	// pipelineMaxStall is how long the event consumer may make no progress while events are
+	// queued before the probes fail.
+	pipelineMaxStall string
+}
+
+type shutdownConfig struct {
+	// drainTimeout bounds handling the buffered events after a shutdown signal;
+	// events left after it are stored as dead letters.
+	drainTimeout string
+	// httpTimeout bounds waiting for HTTP requests in flight.
+	httpTimeout string
+}
+
+type weightsTransferConfig struct {
+	// timeout drops a chunked transfer that received no chunk for this long.
+	timeout string
+	// maxSize is the maximum size of a reassembled transfer in bytes.
+	maxSize int64
+	// maxTotalSize is the maximum number of bytes held by all transfers in progress.
+	maxTotalSize int64
+}
+
+type cacheConfig struct {
+	// ensureSize is the maximum number of trainings, clients and graphs each kept by the ensure cache.
+	ensureSize int
+}
+
+type logPullerConfig struct {
+	// concurrency is the maximum number of pod log streams being opened at once; 0 means
+	// unlimited. Streams that are open hold no slot, so every pod is followed however many
+	// there are.
+	concurrency int
+	// maxLineSize is the longest log line in bytes that is parsed; longer lines are skipped.
+	maxLineSize int
+}
+
+// mount builds the HTTP router of the query API.
+func (app *application) mount() http.Handler {
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	timeout := middleware.Timeout(60 * time.Second)
+
+	r.Handle("/metrics", app.metrics.handler())
+	r.Get("/healthz", app.healthzHandler)
+	r.Get("/readyz", app.readyzHandler)
+
+	r.Route("/v1", func(r chi.Router) {
+		r.With(timeout).Get("/events", app.listEventsHandler)
+
+		r.Route("/trainings", func(r chi.Router) {
+			r.With(timeout).Get("/", app.listTrainingsHandler)
+
+			r.Route("/{flTrainingID}", func(r chi.Router) {
+				r.Group(func(r chi.Router) {
+					r.Use(timeout)
+					r.Get("/", app.getTrainingHandler)
+					r.Get("/clients", app.listTrainingClientsHandler)
+					r.Get("/export/training-points", app.exportTrainingPointsHandler)
+					r.Get("/export/testing-points", app.exportTestingPointsHandler)
+					r.Get("/export/tfevents", app.exportTensorBoardHandler)
+					r.Get("/weights", app.listModelWeightsHandler)
+					r.Get("/weights/diff", app.diffModelWeightsHandler)
+					r.Get("/weights/{serverRound}", app.downloadModelWeightsHandler)
+					r.Get("/weights/{serverRound}/stats", app.getModelWeightsStatsHandler)
+				})
+
+				// The stream stays open while the client listens, so it is exempt from the timeout.
+				r.Get("/events/stream", app.streamTrainingEventsHandler)
+			})
+		})
+
+		r.Route("/clients/{clientID}", func(r chi.Router) {
+			r.Use(timeout)
+			r.Get("/", app.getClientHandler)
+			r.Get("/training-graphs", app.listClientTrainingGraphsHandler)
+			r.Get("/testing-graph", app.getClientTestingGraphHandler)
+			r.Get("/logs", app.listClientLogsHandler)
+		})
+
+		r.Route("/dead-letters", func(r chi.Router) {
+			r.Use(timeout)
+			r.Get("/", app.listDeadLettersHandler)
+			r.Post("/redrive", app.redriveDeadLettersHandler)
+
+			r.Route("/{deadLetterID}", func(r chi.Router) {
+				r.Get("/", app.getDeadLetterHandler)
+				r.Post("/redrive", app.redriveDeadLetterHandler)
+			})
+		})
+	})
+
+	return r
+}
+
+// run serves mux on the configured address until ctx is canceled, then shuts the server
+// down, waiting up to shutdownTimeout for requests in flight.
+func (app *application) run(ctx context.Context, mux http.Handler, shutdownTimeout time.Duration) error {
+	srv := &http.Server{
+		Addr:         app.config.addr,
+		Handler:      mux,
+		WriteTimeout: 30 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		IdleTimeout:  time.Minute,
+	}
+
+	// event streams never finish on their own, so end them when the server shuts down
+	srv.RegisterOnShutdown(app.broadcaster.closeAll)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	app.logger.Infow("http server has started", "addr", app.config.addr, "env", app.config.env)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	app.logger.Info("http server is shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		app.logger.Warnw("http server did not shut down in time, closing connections", "error", err)
+		return srv.Close()
+	}
+
+	return nil
+}
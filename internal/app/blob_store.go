@@ -0,0 +1,19 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/blob"
+)
+
+// newBlobStore returns the blob backend selected by cfg.backend.
+func newBlobStore(cfg blobConfig) (blob.Store, error) {
+	switch cfg.backend {
+	case "fs":
+		return blob.NewFSStore(cfg.fsRoot)
+	case "s3":
+		return blob.NewS3Store(cfg.s3)
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q, expected fs or s3", cfg.backend)
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	errDeadLetterResolved      = errors.New("dead letter is already resolved")
+	errDeadLetterNotRedrivable = errors.New("truncated dead letter cannot be re-driven")
+)
+
+// truncatedRawLineSize is how much of an oversized log line is kept in its dead letter.
+const truncatedRawLineSize = 4 * 1024
+
+type redriveCtxKey struct{}
+
+// withRedrive marks ctx as re-driving a dead letter. Such events are older than the
+// last_log_read markers by definition, so the dedup checks must let them through.
+func withRedrive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redriveCtxKey{}, true)
+}
+
+func isRedrive(ctx context.Context) bool {
+	v, _ := ctx.Value(redriveCtxKey{}).(bool)
+	return v
+}
+
+// recordParseFailure stores a log line whose JSON message could not be decoded.
+// env carries the Kubernetes metadata of the line.
+func (app *application) recordParseFailure(ctx context.Context, env Envelope, msg string, cause error) {
+	app.recordDeadLetter(ctx, store.DeadLetterEvent{
+		Stage:        store.DeadLetterStageParse,
+		RawLine:      msg,
+		PodName:      env.PodName,
+		NodeName:     env.NodeName,
+		Component:    env.Component,
+		LogTimestamp: env.Timestamp,
+		Error:        cause.Error(),
+	})
+}
+
+// recordTruncatedLine stores the beginning of a log line that exceeded the reader limit
+// and was skipped, so it is known which pod and timestamp lost an event.
+func (app *application) recordTruncatedLine(ctx context.Context, pod corev1.Pod, ts time.Time, line string) {
+	if len(line) > truncatedRawLineSize {
+		line = line[:truncatedRawLineSize]
+	}
+	// the cut may split a character, and Postgres rejects invalid UTF-8
+	line = strings.ToValidUTF8(line, "")
+
+	app.recordDeadLetter(ctx, store.DeadLetterEvent{
+		Stage:        store.DeadLetterStageTruncated,
+		RawLine:      line,
+		PodName:      pod.Name,
+		NodeName:     pod.Spec.NodeName,
+		Component:    pod.Labels[componentLabel],
+		LogTimestamp: ts,
+		Error:        fmt.Sprintf("log line exceeds %d bytes", app.config.logPuller.maxLineSize),
+	})
+}
+
+// recordHandleFailure stores an event whose handler failed.
+func (app *application) recordHandleFailure(ctx context.Context, env Envelope, cause error) {
+	raw, err := json.Marshal(env)
+	if err != nil {
+		app.logger.Errorw("failed to encode dead letter event", "event", env.Event, "error", err)
+		return
+	}
+
+	app.recordDeadLetter(ctx, store.DeadLetterEvent{
+		Stage:        store.DeadLetterStageHandle,
+		RawLine:      string(raw),
+		PodName:      env.PodName,
+		NodeName:     env.NodeName,
+		Component:    env.Component,
+		Event:        env.Event,
+		LogTimestamp: env.Timestamp,
+		Error:        cause.Error(),
+	})
+}
+
+func (app *application) recordDeadLetter(ctx context.Context, dl store.DeadLetterEvent) {
+	if err := app.store.DeadLetterEvents.Create(ctx, dl); err != nil {
+		app.logger.Errorw("failed to store dead letter event",
+			"stage", dl.Stage,
+			"event", dl.Event,
+			"pod", dl.PodName,
+			"ts", dl.LogTimestamp,
+			"cause", dl.Error,
+			"error", err,
+		)
+	}
+}
+
+// redriveDeadLetter parses and handles a dead letter again, marking it resolved on success
+// and recording the new error on failure.
+func (app *application) redriveDeadLetter(ctx context.Context, dl store.DeadLetterEvent) error {
+	if dl.ResolvedAt != nil {
+		return errDeadLetterResolved
+	}
+	if dl.Stage == store.DeadLetterStageTruncated {
+		return errDeadLetterNotRedrivable
+	}
+
+	// the consumer must not handle events of the same client or training meanwhile
+	app.handling.Lock()
+	err := app.handleDeadLetter(withRedrive(ctx), dl)
+	app.handling.Unlock()
+	if err != nil {
+		if recErr := app.store.DeadLetterEvents.RecordFailedAttempt(ctx, dl.ID, err.Error()); recErr != nil {
+			app.logger.Errorw("failed to record dead letter attempt", "id", dl.ID, "error", recErr)
+		}
+		return err
+	}
+
+	if err := app.store.DeadLetterEvents.MarkResolved(ctx, dl.ID); err != nil {
+		return fmt.Errorf("mark dead letter resolved: %w", err)
+	}
+
+	app.logger.Infow("re-drove dead letter event",
+		"id", dl.ID,
+		"stage", dl.Stage,
+		"event", dl.Event,
+		"pod", dl.PodName,
+	)
+
+	return nil
+}
+
+func (app *application) handleDeadLetter(ctx context.Context, dl store.DeadLetterEvent) error {
+	var env Envelope
+	if err := json.Unmarshal([]byte(dl.RawLine), &env); err != nil {
+		return fmt.Errorf("unmarshal dead letter: %w", err)
+	}
+
+	env.PodName = dl.PodName
+	env.NodeName = dl.NodeName
+	env.Component = dl.Component
+	env.Timestamp = dl.LogTimestamp
+
+	_, err := app.eventMux(ctx, env)
+	return err
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// domainCollectTimeout bounds the store queries of one scrape.
+const domainCollectTimeout = 10 * time.Second
+
+// domainCollector exports the state of the trainings as gauges, read from the store on every scrape.
+// Every series is keyed by training or client, never by round, so the number of series does not
+// grow while a training runs.
+type domainCollector struct {
+	store  *store.Storage
+	logger *zap.SugaredLogger
+	// ctx is the context of the scrape; see withContext.
+	ctx context.Context
+
+	up              *prometheus.Desc
+	currentRound    *prometheus.Desc
+	totalRounds     *prometheus.Desc
+	clients         *prometheus.Desc
+	clientRound     *prometheus.Desc
+	clientTrainLoss *prometheus.Desc
+	clientValLoss   *prometheus.Desc
+	clientAccuracy  *prometheus.Desc
+	testRound       *prometheus.Desc
+	testAccuracy    *prometheus.Desc
+	testLoss        *prometheus.Desc
+}
+
+func newDomainCollector(s *store.Storage, logger *zap.SugaredLogger) *domainCollector {
+	training := []string{"fl_training_id"}
+	client := []string{"fl_training_id", "partition_id"}
+
+	return &domainCollector{
+		store:  s,
+		logger: logger,
+
+		up: prometheus.NewDesc("fl_store_up",
+			"Whether the last read of the training gauges from the store succeeded.", nil, nil),
+		currentRound: prometheus.NewDesc("fl_training_current_round",
+			"Current server round of a training.", training, nil),
+		totalRounds: prometheus.NewDesc("fl_training_total_rounds",
+			"Total number of server rounds of a training; absent until the server announces it.", training, nil),
+		clients: prometheus.NewDesc("fl_training_clients",
+			"Number of clients of a training by state.", []string{"fl_training_id", "state"}, nil),
+		clientRound: prometheus.NewDesc("fl_client_latest_round",
+			"Server round of the latest training point of a client.", client, nil),
+		clientTrainLoss: prometheus.NewDesc("fl_client_train_loss",
+			"Train loss of the latest epoch of a client.", client, nil),
+		clientValLoss: prometheus.NewDesc("fl_client_val_loss",
+			"Validation loss of the latest epoch of a client.", client, nil),
+		clientAccuracy: prometheus.NewDesc("fl_client_accuracy",
+			"Accuracy of the latest epoch of a client.", client, nil),
+		testRound: prometheus.NewDesc("fl_training_test_round",
+			"Server round of the latest test results of a training.", training, nil),
+		testAccuracy: prometheus.NewDesc("fl_training_test_accuracy",
+			"Mean test accuracy of the clients of a training in its latest tested round.", training, nil),
+		testLoss: prometheus.NewDesc("fl_training_test_loss",
+			"Mean test loss of the clients of a training in its latest tested round.", training, nil),
+	}
+}
+
+// withContext returns a copy of the collector whose queries run under ctx, so a scrape that is
+// canceled or times out also cancels its queries.
+func (c *domainCollector) withContext(ctx context.Context) *domainCollector {
+	scrape := *c
+	scrape.ctx = ctx
+	return &scrape
+}
+
+func (c *domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.currentRound
+	ch <- c.totalRounds
+	ch <- c.clients
+	ch <- c.clientRound
+	ch <- c.clientTrainLoss
+	ch <- c.clientValLoss
+	ch <- c.clientAccuracy
+	ch <- c.testRound
+	ch <- c.testAccuracy
+	ch <- c.testLoss
+}
+
+// Collect reads the gauges from the store. If a query fails the gauges read so far are still
+// exported and fl_store_up is 0.
+func (c *domainCollector) Collect(ch chan<- prometheus.Metric) {
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, domainCollectTimeout)
+	defer cancel()
+
+	up := 1.0
+	if err := c.collect(ctx, ch); err != nil {
+		c.logger.Errorw("failed to collect training metrics", "error", err)
+		up = 0
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+}
+
+func (c *domainCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	trainings, err := c.store.FLTrainings.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range trainings {
+		ch <- prometheus.MustNewConstMetric(c.currentRound, prometheus.GaugeValue,
+			float64(t.CurrentServerRound), t.FLTrainingID)
+		if t.TotalServerRound >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.totalRounds, prometheus.GaugeValue,
+				float64(t.TotalServerRound), t.FLTrainingID)
+		}
+	}
+
+	counts, err := c.store.FLTrainingClients.CountByState(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sc := range counts {
+		ch <- prometheus.MustNewConstMetric(c.clients, prometheus.GaugeValue,
+			float64(sc.Count), sc.FLTrainingID, sc.State)
+	}
+
+	points, err := c.store.TrainingGraphs.GetLatestPoints(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range points {
+		partition := strconv.Itoa(p.PartitionID)
+		ch <- prometheus.MustNewConstMetric(c.clientRound, prometheus.GaugeValue,
+			float64(p.ServerRound), p.FLTrainingID, partition)
+		c.optionalGauge(ch, c.clientTrainLoss, p.TrainLoss, p.FLTrainingID, partition)
+		c.optionalGauge(ch, c.clientValLoss, p.ValLoss, p.FLTrainingID, partition)
+		c.optionalGauge(ch, c.clientAccuracy, p.Accuracy, p.FLTrainingID, partition)
+	}
+
+	results, err := c.store.TestingGraphs.GetLatestRoundResults(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		ch <- prometheus.MustNewConstMetric(c.testRound, prometheus.GaugeValue,
+			float64(r.ServerRound), r.FLTrainingID)
+		c.optionalGauge(ch, c.testAccuracy, r.Accuracy, r.FLTrainingID)
+		c.optionalGauge(ch, c.testLoss, r.TestLoss, r.FLTrainingID)
+	}
+
+	return nil
+}
+
+// optionalGauge exports v unless the value was never reported.
+func (c *domainCollector) optionalGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, v *float64, labels ...string) {
+	if v == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *v, labels...)
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/lru"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/google/uuid"
+)
+
+type clientCacheKey struct {
+	flTrainingID string
+	partitionID  int
+}
+
+type trainingGraphCacheKey struct {
+	flTrainingID string
+	partitionID  int
+	serverRound  int
+}
+
+// ensureCache remembers the rows returned by the ensure helpers, so an event for a
+// known training, client and graph does not have to look them up again.
+//
+// Entries are written while the event's transaction is still open. If a transaction
+// fails, the whole cache is purged, so it never refers to rows that were rolled back.
+type ensureCache struct {
+	trainings      *lru.Cache[string, store.FLTraining]
+	clients        *lru.Cache[clientCacheKey, store.FLTrainingClient]
+	trainingGraphs *lru.Cache[trainingGraphCacheKey, store.TrainingGraph]
+	testingGraphs  *lru.Cache[uuid.UUID, store.TestingGraph]
+}
+
+func newEnsureCache(size int) *ensureCache {
+	return &ensureCache{
+		trainings:      lru.New[string, store.FLTraining](size),
+		clients:        lru.New[clientCacheKey, store.FLTrainingClient](size),
+		trainingGraphs: lru.New[trainingGraphCacheKey, store.TrainingGraph](size),
+		testingGraphs:  lru.New[uuid.UUID, store.TestingGraph](size),
+	}
+}
+
+// invalidateTraining drops a training whose rounds were updated.
+func (c *ensureCache) invalidateTraining(flTrainingID string) {
+	c.trainings.Remove(flTrainingID)
+}
+
+// invalidateClient drops a client whose state was updated.
+func (c *ensureCache) invalidateClient(flTrainingID string, partitionID int) {
+	c.clients.Remove(clientCacheKey{flTrainingID, partitionID})
+}
+
+// invalidateTrainingGraph drops a graph whose configuration was updated.
+func (c *ensureCache) invalidateTrainingGraph(flTrainingID string, partitionID, serverRound int) {
+	c.trainingGraphs.Remove(trainingGraphCacheKey{flTrainingID, partitionID, serverRound})
+}
+
+// advanceClientLastLogRead moves the cached last_log_read of a client forward. The client is
+// updated in place instead of dropped because nearly every event advances it.
+func (c *ensureCache) advanceClientLastLogRead(flTrainingID string, partitionID int, ts time.Time) {
+	c.clients.Update(clientCacheKey{flTrainingID, partitionID}, func(client store.FLTrainingClient) store.FLTrainingClient {
+		if ts.After(client.LastLogRead) {
+			client.LastLogRead = ts
+		}
+		return client
+	})
+}
+
+// purge drops every entry.
+func (c *ensureCache) purge() {
+	c.trainings.Purge()
+	c.clients.Purge()
+	c.trainingGraphs.Purge()
+	c.testingGraphs.Purge()
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+// ensureTraining guarantees that a training row exists for the given ID.
+func (app *application) ensureTraining(ctx context.Context, flTrainingID string) (store.FLTraining, error) {
+	if t, ok := app.ensured.trainings.Get(flTrainingID); ok {
+		return t, nil
+	}
+
+	t, err := app.storage(ctx).FLTrainings.Ensure(ctx, flTrainingID)
+	if err != nil {
+		return store.FLTraining{}, fmt.Errorf("ensureTraining(%s): %w", flTrainingID, err)
+	}
+
+	app.ensured.trainings.Add(flTrainingID, t)
+	return t, nil
+}
+
+// ensureClient guarantees that the client of a training/partition exists.
+// A new client is created on the node and pod of env, in the given initial state.
+func (app *application) ensureClient(
+	ctx context.Context,
+	env Envelope,
+	flTrainingID string,
+	partitionID int,
+	state string,
+) (store.FLTrainingClient, error) {
+	key := clientCacheKey{flTrainingID, partitionID}
+	if c, ok := app.ensured.clients.Get(key); ok {
+		return c, nil
+	}
+
+	c, err := app.storage(ctx).FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
+		ctx,
+		flTrainingID,
+		partitionID,
+		env.NodeName,
+		env.PodName,
+		state,
+	)
+	if err != nil {
+		return store.FLTrainingClient{}, err
+	}
+
+	app.ensured.clients.Add(key, c)
+	return c, nil
+}
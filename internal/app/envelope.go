@@ -1,20 +1,14 @@
-package main
+package app
 
 import (
 	"encoding/json"
-	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/events"
 )
 
 // Envelope represents a single structured log event coming from a pod log line.
-// The raw message from Kubernetes is a JSON object that is unmarshaled into this struct.
-type Envelope struct {
-	Event     string          `json:"event"`
-	Payload   json.RawMessage `json:"payload"`
-	PodName   string          `json:"-"`
-	NodeName  string          `json:"-"`
-	Component string          `json:"-"` // e.g. "clientapp" or "serverapp"
-	Timestamp time.Time       `json:"-"` // timestamp parsed from Kubernetes log line
-}
+// It lives in internal/events so that event handlers outside this package can use it.
+type Envelope = events.Envelope
 
 // Client-side events (component = "clientapp")
 
@@ -83,5 +77,31 @@ type CreateFLTrainingPayload struct {
 type ModelWeightsPayload struct {
 	FLTrainingID string          `json:"fl_training_id"`
 	ServerRound  int             `json:"server_round"`
-	Layers       json.RawMessage `json:"layers"` // stored as JSONB; caller does not inspect it directly
+	Layers       json.RawMessage `json:"layers"` // parsed by weights.ParseJSON
+}
+
+// MODEL_WEIGHTS_BEGIN, MODEL_WEIGHTS_CHUNK and MODEL_WEIGHTS_END carry weights that are too large
+// for one log line. The chunk data, joined in seq order, is the "layers" value of a MODEL_WEIGHTS
+// payload; SHA256 is the hex SHA-256 of the joined data.
+
+type ModelWeightsBeginPayload struct {
+	FLTrainingID string `json:"fl_training_id"`
+	ServerRound  int    `json:"server_round"`
+	TransferID   string `json:"transfer_id"`
+	TotalChunks  int    `json:"total_chunks"`
+}
+
+type ModelWeightsChunkPayload struct {
+	FLTrainingID string `json:"fl_training_id"`
+	ServerRound  int    `json:"server_round"`
+	TransferID   string `json:"transfer_id"`
+	Seq          int    `json:"seq"`  // 0-based
+	Data         []byte `json:"data"` // base64 in JSON
+}
+
+type ModelWeightsEndPayload struct {
+	FLTrainingID string `json:"fl_training_id"`
+	ServerRound  int    `json:"server_round"`
+	TransferID   string `json:"transfer_id"`
+	SHA256       string `json:"sha256"`
 }
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// subscriberBufferSize is how many envelopes a single subscriber may lag behind
+// before new envelopes are dropped for it.
+const subscriberBufferSize = 64
+
+// eventBroadcaster fans out handled envelopes to subscribers of a training.
+// Publishing never blocks: a subscriber that cannot keep up misses events
+// instead of stalling the event consumer.
+type eventBroadcaster struct {
+	mu     sync.RWMutex
+	subs   map[string]map[chan Envelope]struct{} // keyed by fl_training_id
+	closed bool
+}
+
+func newEventBroadcaster() *eventBroadcaster {
+	return &eventBroadcaster{
+		subs: make(map[string]map[chan Envelope]struct{}),
+	}
+}
+
+// subscribe registers a new listener for a training. The returned function
+// must be called to unregister it; it closes the channel.
+func (b *eventBroadcaster) subscribe(flTrainingID string) (<-chan Envelope, func()) {
+	ch := make(chan Envelope, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs[flTrainingID] == nil {
+		b.subs[flTrainingID] = make(map[chan Envelope]struct{})
+	}
+	b.subs[flTrainingID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			// closeAll may have closed the channel already
+			if _, ok := b.subs[flTrainingID][ch]; !ok {
+				return
+			}
+			delete(b.subs[flTrainingID], ch)
+			if len(b.subs[flTrainingID]) == 0 {
+				delete(b.subs, flTrainingID)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// publish delivers env to every subscriber of its training.
+// It returns the number of subscribers that had to drop the envelope.
+func (b *eventBroadcaster) publish(env Envelope) int {
+	flTrainingID := envelopeFLTrainingID(env)
+	if flTrainingID == "" {
+		return 0
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	dropped := 0
+	for ch := range b.subs[flTrainingID] {
+		select {
+		case ch <- env:
+		default:
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// closeAll closes every subscriber channel, ending their streams, and refuses new subscribers.
+func (b *eventBroadcaster) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, chans := range b.subs {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	b.subs = make(map[string]map[chan Envelope]struct{})
+	b.closed = true
+}
+
+// envelopeFLTrainingID extracts the fl_training_id shared by every payload type.
+func envelopeFLTrainingID(env Envelope) string {
+	var p struct {
+		FLTrainingID string `json:"fl_training_id"`
+	}
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return ""
+	}
+	return p.FLTrainingID
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+var errShutdownBeforeHandled = errors.New("shut down before the event was handled")
+
+// queuedEvent is an item of the events channel. The consumer advances cursor, the position
+// of the log line the item was read from, only after env is handled or stored as a dead
+// letter, so a persisted cursor never moves past an event that was still queued.
+// env is nil for items that only move the cursor past lines without events; cursor is nil
+// for events that were not pulled from a pod, e.g. ingested files.
+type queuedEvent struct {
+	env    *Envelope
+	cursor *store.LogPullerCursor
+}
+
+// consumeEvents handles the envelopes from events until the channel is closed.
+// Once abandon is closed, envelopes still buffered are stored as dead letters instead of
+// being handled, so a shutdown that runs out of time loses none of them.
+//
+// READLINE batches and log cursors are written from this loop too, so a checkpoint always
+// sees every event before the cursors it persists either stored or dead-lettered.
+func (app *application) consumeEvents(events <-chan queuedEvent, abandon <-chan struct{}) {
+	ctx := context.Background()
+	abandoned := 0
+
+	readlineTicker := time.NewTicker(max(app.readlines.maxAge/2, 10*time.Millisecond))
+	defer readlineTicker.Stop()
+
+	checkpointTicker := time.NewTicker(cursorFlushInterval)
+	defer checkpointTicker.Stop()
+
+	app.logger.Info("event consumer started")
+	for {
+		var item queuedEvent
+		var ok bool
+
+		app.pipelineHealth.touch()
+		select {
+		case item, ok = <-events:
+		case <-readlineTicker.C:
+			app.handling.Lock()
+			app.flushReadlines(ctx, false)
+			app.handling.Unlock()
+			continue
+		case <-checkpointTicker.C:
+			app.handling.Lock()
+			app.checkpoint(ctx)
+			app.handling.Unlock()
+			continue
+		}
+		if !ok {
+			break
+		}
+
+		app.handling.Lock()
+		if item.env != nil {
+			select {
+			case <-abandon:
+				app.recordHandleFailure(ctx, *item.env, errShutdownBeforeHandled)
+				abandoned++
+			default:
+				app.consumeEvent(withLogSource(ctx, item.cursor), *item.env)
+			}
+		}
+
+		if item.cursor != nil {
+			app.cursors.advance(*item.cursor)
+		}
+		app.handling.Unlock()
+	}
+
+	// write the pending READLINE batches and the final position of every follower
+	finalCtx, cancel := context.WithTimeout(ctx, cursorFinalFlushTimeout)
+	defer cancel()
+	app.handling.Lock()
+	app.checkpoint(finalCtx)
+	app.handling.Unlock()
+
+	if abandoned > 0 {
+		app.logger.Warnw("drain deadline passed, stored remaining events as dead letters", "events", abandoned)
+	}
+	app.logger.Info("event consumer stopped")
+}
+
+// consumeEvent handles env, storing it as a dead letter if that fails, and publishes it to
+// the stream subscribers unless it is unknown or was skipped.
+func (app *application) consumeEvent(ctx context.Context, env Envelope) {
+	handled, err := app.eventMux(ctx, env)
+	if err != nil {
+		app.logger.Errorw("handle event error", "event", env.Event, "pod", env.PodName, "err", err)
+		app.recordHandleFailure(ctx, env, err)
+		return
+	}
+
+	if handled {
+		if dropped := app.broadcaster.publish(env); dropped > 0 {
+			app.logger.Debugw("slow stream subscribers dropped event",
+				"event", env.Event,
+				"dropped", dropped,
+			)
+		}
+	}
+
+	// write READLINE batches that became full with this event
+	app.flushReadlines(ctx, false)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/events"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+const (
+	componentClientApp = "clientapp"
+	componentServerApp = "serverapp"
+
+	// componentLabel is the pod label holding the component of its events.
+	componentLabel = "component"
+)
+
+type skippedCtxKey struct{}
+
+// markSkipped records that the event being handled was read before, e.g. a line re-read
+// after a reconnect, or holds nothing to store. Skipped events are not published to the
+// stream subscribers, who saw them when they were first read.
+func markSkipped(ctx context.Context) {
+	if skipped, ok := ctx.Value(skippedCtxKey{}).(*bool); ok {
+		*skipped = true
+	}
+}
+
+// eventMux routes events to the handler registered for their (component, event). It reports
+// whether the event is new: neither unknown nor skipped by its handler.
+func (app *application) eventMux(ctx context.Context, env Envelope) (bool, error) {
+	if _, ok := app.registry.Lookup(env.Component, env.Event); !ok {
+		app.metrics.observeUnknownEvent()
+		app.logger.Warnw("unknown event type, skipping event",
+			"component", env.Component,
+			"event", env.Event,
+			"payload", string(env.Payload),
+			"pod", env.PodName,
+			"node", env.NodeName,
+		)
+		return false, nil
+	}
+
+	app.logger.Infow("handling event",
+		"component", env.Component,
+		"event", env.Event,
+		"pod", env.PodName,
+		"node", env.NodeName,
+		"ts", env.Timestamp,
+	)
+
+	start := time.Now()
+
+	// All writes of one event commit together, so data and last_log_read never diverge.
+	var committed []func()
+	var skipped bool
+	ctx = context.WithValue(ctx, skippedCtxKey{}, &skipped)
+	err := app.store.WithTx(ctx, func(tx *store.Storage) error {
+		return app.registry.Dispatch(withCommitHooks(withTxStorage(ctx, tx), &committed), env)
+	})
+	if err != nil {
+		// The cache may hold rows written by the rolled back transaction.
+		app.ensured.purge()
+	} else {
+		for _, fn := range committed {
+			fn()
+		}
+	}
+	app.metrics.observeEvent(env.Component, env.Event, time.Since(start), err)
+
+	return err == nil && !skipped, err
+}
+
+// registerEventHandlers registers the built-in client and server events.
+// Other packages add theirs with the WithEventRegistrations option.
+func (app *application) registerEventHandlers() error {
+	r := app.registry
+
+	return errors.Join(
+		// Client-side events (component = "clientapp")
+		events.Register(r, events.Spec[ReadlinePayload]{
+			Component:   componentClientApp,
+			Event:       "READLINE",
+			Description: "a line of client output, stored in client_logs",
+			Validate:    func(p ReadlinePayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleReadline,
+		}),
+		events.Register(r, events.Spec[SetStatePayload]{
+			Component:   componentClientApp,
+			Event:       "SETSTATE",
+			Description: "changes the state of a training client",
+			Validate:    func(p SetStatePayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleSetState,
+		}),
+		events.Register(r, events.Spec[CreateTrainingGraphPayload]{
+			Component:   componentClientApp,
+			Event:       "CREATE_TRAINING_GRAPH",
+			Description: "creates the training graph of a client for a server round",
+			Validate:    func(p CreateTrainingGraphPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleCreateTrainingGraph,
+		}),
+		events.Register(r, events.Spec[AddOneEpochTrainingGraphPointPayload]{
+			Component:   componentClientApp,
+			Event:       "ADD_ONE_EPOCH_TRAINING_GRAPH_POINT",
+			Description: "adds the metrics of one training epoch to a training graph",
+			Validate:    func(p AddOneEpochTrainingGraphPointPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleAddOneEpochTrainingGraphPoint,
+		}),
+		events.Register(r, events.Spec[CreateTestingGraphPayload]{
+			Component:   componentClientApp,
+			Event:       "CREATE_TESTING_GRAPH",
+			Description: "creates the testing graph of a client",
+			Validate:    func(p CreateTestingGraphPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleCreateTestingGraph,
+		}),
+		events.Register(r, events.Spec[AddOneServerRoundTestingGraphPointPayload]{
+			Component:   componentClientApp,
+			Event:       "ADD_ONE_SERVER_ROUND_TESTING_GRAPH_POINT",
+			Description: "adds the test metrics of one server round to a testing graph",
+			Validate:    func(p AddOneServerRoundTestingGraphPointPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleAddOneServerRoundTestingGraphPoint,
+		}),
+		events.Register(r, events.Spec[SetCurrentServerRoundPayload]{
+			Component:   componentClientApp,
+			Event:       "SET_CURRENT_SERVER_ROUND",
+			Description: "advances the current server round of a training",
+			Validate:    func(p SetCurrentServerRoundPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleSetCurrentServerRound,
+		}),
+
+		// Server-side events (component = "serverapp")
+		events.Register(r, events.Spec[CreateFLTrainingPayload]{
+			Component:   componentServerApp,
+			Event:       "CREATE_FL_TRAINING",
+			Description: "announces a training and its total number of rounds",
+			Validate:    func(p CreateFLTrainingPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleCreateFLTraining,
+		}),
+		events.Register(r, events.Spec[ModelWeightsPayload]{
+			Component:   componentServerApp,
+			Event:       "MODEL_WEIGHTS",
+			Description: "the aggregated model weights of a server round",
+			Validate:    func(p ModelWeightsPayload) error { return validateFLTrainingID(p.FLTrainingID) },
+			Handle:      app.handleModelWeights,
+		}),
+		events.Register(r, events.Spec[ModelWeightsBeginPayload]{
+			Component:   componentServerApp,
+			Event:       "MODEL_WEIGHTS_BEGIN",
+			Description: "starts a chunked transfer of the model weights of a server round",
+			Validate: func(p ModelWeightsBeginPayload) error {
+				if p.TotalChunks < 1 {
+					return errors.New("total_chunks must be positive")
+				}
+				return validateWeightsTransfer(p.FLTrainingID, p.TransferID)
+			},
+			Handle: app.handleModelWeightsBegin,
+		}),
+		events.Register(r, events.Spec[ModelWeightsChunkPayload]{
+			Component:   componentServerApp,
+			Event:       "MODEL_WEIGHTS_CHUNK",
+			Description: "one base64 chunk of a model weights transfer",
+			Validate: func(p ModelWeightsChunkPayload) error {
+				return validateWeightsTransfer(p.FLTrainingID, p.TransferID)
+			},
+			Handle: app.handleModelWeightsChunk,
+		}),
+		events.Register(r, events.Spec[ModelWeightsEndPayload]{
+			Component:   componentServerApp,
+			Event:       "MODEL_WEIGHTS_END",
+			Description: "completes a model weights transfer; the weights are stored if the checksum matches",
+			Validate: func(p ModelWeightsEndPayload) error {
+				if p.SHA256 == "" {
+					return errors.New("sha256 is required")
+				}
+				return validateWeightsTransfer(p.FLTrainingID, p.TransferID)
+			},
+			Handle: app.handleModelWeightsEnd,
+		}),
+	)
+}
+
+// validateFLTrainingID rejects payloads that do not name their training;
+// every built-in event belongs to one.
+func validateFLTrainingID(flTrainingID string) error {
+	if strings.TrimSpace(flTrainingID) == "" {
+		return errors.New("fl_training_id is required")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+// handleReadline buffers a single log line for a client. The line is written, and
+// last_log_read advanced, when the client's READLINE batch is flushed.
+func (app *application) handleReadline(
+	ctx context.Context,
+	env Envelope,
+	p ReadlinePayload,
+) error {
+	// Ensure training exists.
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
+		return err
+	}
+
+	// Ensure client exists ("init" is the initial state for a newly discovered client).
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "init")
+	if err != nil {
+		return err
+	}
+
+	// Lines are not skipped by last_log_read: the client's other events advance it while
+	// older lines may still wait in the batch, and those are re-read after a restart.
+	// The unique key of client_logs makes writing a line again a no-op instead. A line at
+	// or before last_log_read was read before, so it is only not published again.
+	if !isRedrive(ctx) && !env.Timestamp.After(client.LastLogRead) {
+		markSkipped(ctx)
+	}
+
+	log := store.ClientLog{
+		ClientID:       client.ID,
+		Text:           p.Text,
+		ClientOutputAt: env.Timestamp,
+	}
+
+	// A re-driven line is written right away so that it is only resolved once stored.
+	if isRedrive(ctx) {
+		if err := app.storage(ctx).ClientLogs.Create(ctx, log); err != nil {
+			return err
+		}
+		return app.updateClientLastLogRead(ctx, client, env.Timestamp)
+	}
+
+	// Buffer log entry once the event committed; it is written with the rest of the
+	// client's batch, and a rolled back event is only stored as a dead letter.
+	onCommit(ctx, func() { app.readlines.add(client, log, env) })
+
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package app
 
 import (
 	"context"
@@ -11,30 +11,23 @@ func (app *application) handleSetState(
 	p SetStatePayload,
 ) error {
 	// Ensure training exists.
-	if _, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID); err != nil {
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
 		return err
 	}
 
 	// Ensure client exists (new client will start in the state from this event).
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		p.State,
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, p.State)
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicate/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
 	// Update state in the DB.
-	if err := app.store.FLTrainingClients.UpdateState(
+	if err := app.storage(ctx).FLTrainingClients.UpdateState(
 		ctx,
 		p.FLTrainingID,
 		p.PartitionID,
@@ -42,6 +35,7 @@ func (app *application) handleSetState(
 	); err != nil {
 		return err
 	}
+	app.ensured.invalidateClient(p.FLTrainingID, p.PartitionID)
 
 	app.logger.Infow("client state updated",
 		"fl_training_id", p.FLTrainingID,
@@ -50,9 +44,7 @@ func (app *application) handleSetState(
 	)
 
 	// Update last_log_read marker.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
 
 // handleSetCurrentServerRound updates the current_server_round for a training.
@@ -62,37 +54,31 @@ func (app *application) handleSetCurrentServerRound(
 	p SetCurrentServerRoundPayload,
 ) error {
 	// Ensure training exists.
-	tr, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID)
+	tr, err := app.ensureTraining(ctx, p.FLTrainingID)
 	if err != nil {
 		return err
 	}
 
 	// Ensure client exists (state "init" for this type of event by default).
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		"init",
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "init")
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicate/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
 	// Update current_server_round with monotonic semantics (never decrease).
-	if err := app.store.FLTrainings.UpdateCurrentServerRound(
+	if err := app.storage(ctx).FLTrainings.UpdateCurrentServerRound(
 		ctx,
 		p.FLTrainingID,
 		p.ServerRound,
 	); err != nil {
 		return err
 	}
+	app.ensured.invalidateTraining(p.FLTrainingID)
 
 	app.logger.Infow("current server round updated",
 		"fl_training_id", tr.FLTrainingID,
@@ -100,7 +86,5 @@ func (app *application) handleSetCurrentServerRound(
 	)
 
 	// Update last_log_read marker for the client that emitted this event.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
@@ -1,4 +1,4 @@
-package main
+package app
 
 import (
 	"context"
@@ -14,7 +14,23 @@ func (app *application) ensureTestingGraph(
 	ctx context.Context,
 	clientID uuid.UUID,
 ) (store.TestingGraph, error) {
-	graphs, err := app.store.TestingGraphs.GetGraphsByClientID(ctx, clientID)
+	if g, ok := app.ensured.testingGraphs.Get(clientID); ok {
+		return g, nil
+	}
+
+	g, err := app.findTestingGraph(ctx, clientID)
+	if err == nil {
+		app.ensured.testingGraphs.Add(clientID, g)
+	}
+
+	return g, err
+}
+
+func (app *application) findTestingGraph(
+	ctx context.Context,
+	clientID uuid.UUID,
+) (store.TestingGraph, error) {
+	graphs, err := app.storage(ctx).TestingGraphs.GetGraphsByClientID(ctx, clientID)
 	if err != nil {
 		return store.TestingGraph{}, fmt.Errorf("GetGraphsByClientID: %w", err)
 	}
@@ -27,11 +43,11 @@ func (app *application) ensureTestingGraph(
 		ClientID: clientID,
 	}
 
-	if err := app.store.TestingGraphs.Create(ctx, g); err != nil {
+	if err := app.storage(ctx).TestingGraphs.Create(ctx, g); err != nil {
 		return store.TestingGraph{}, fmt.Errorf("Create testing_graph: %w", err)
 	}
 
-	graphs, err = app.store.TestingGraphs.GetGraphsByClientID(ctx, clientID)
+	graphs, err = app.storage(ctx).TestingGraphs.GetGraphsByClientID(ctx, clientID)
 	if err != nil {
 		return store.TestingGraph{}, fmt.Errorf("GetGraphsByClientID after create: %w", err)
 	}
@@ -50,25 +66,18 @@ func (app *application) handleCreateTestingGraph(
 	p CreateTestingGraphPayload,
 ) error {
 	// Ensure training exists.
-	if _, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID); err != nil {
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
 		return err
 	}
 
 	// Ensure client exists.
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		"test",
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "test")
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicates/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
@@ -78,9 +87,7 @@ func (app *application) handleCreateTestingGraph(
 	}
 
 	// Update last_log_read marker.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
 
 // handleAddOneServerRoundTestingGraphPoint inserts a new testing_graph_point for a client.
@@ -90,25 +97,18 @@ func (app *application) handleAddOneServerRoundTestingGraphPoint(
 	p AddOneServerRoundTestingGraphPointPayload,
 ) error {
 	// Ensure training exists.
-	if _, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID); err != nil {
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
 		return err
 	}
 
 	// Ensure client exists.
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		"test",
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "test")
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicates/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
@@ -118,7 +118,7 @@ func (app *application) handleAddOneServerRoundTestingGraphPoint(
 		return err
 	}
 
-	// Insert a new point for this server_round.
+	// Insert or overwrite the point for this server_round.
 	point := store.TestingGraphPoint{
 		GraphID:     graph.ID,
 		ServerRound: p.ServerRound,
@@ -126,14 +126,13 @@ func (app *application) handleAddOneServerRoundTestingGraphPoint(
 		BatchSize:   p.BatchSize,
 		TestLoss:    p.TestLoss,
 		Accuracy:    p.Accuracy,
+		EventAt:     env.Timestamp,
 	}
 
-	if err := app.store.TestingGraphs.CreatePoint(ctx, point); err != nil {
+	if err := app.storage(ctx).TestingGraphs.CreatePoint(ctx, point); err != nil {
 		return err
 	}
 
 	// Update last_log_read marker.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
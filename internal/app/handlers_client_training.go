@@ -1,4 +1,4 @@
-package main
+package app
 
 import (
 	"context"
@@ -12,11 +12,29 @@ import (
 // ensureTrainingGraphForRound returns a training_graph for (client, server_round),
 // creating a minimal one if it does not yet exist.
 func (app *application) ensureTrainingGraphForRound(
+	ctx context.Context,
+	client store.FLTrainingClient,
+	serverRound int,
+) (store.TrainingGraph, error) {
+	key := trainingGraphCacheKey{client.FLTrainingID, client.PartitionID, serverRound}
+	if g, ok := app.ensured.trainingGraphs.Get(key); ok {
+		return g, nil
+	}
+
+	g, err := app.findTrainingGraphForRound(ctx, client.ID, serverRound)
+	if err == nil {
+		app.ensured.trainingGraphs.Add(key, g)
+	}
+
+	return g, err
+}
+
+func (app *application) findTrainingGraphForRound(
 	ctx context.Context,
 	clientID uuid.UUID,
 	serverRound int,
 ) (store.TrainingGraph, error) {
-	graphs, err := app.store.TrainingGraphs.GetGraphsByClientID(ctx, clientID)
+	graphs, err := app.storage(ctx).TrainingGraphs.GetGraphsByClientID(ctx, clientID)
 	if err != nil {
 		return store.TrainingGraph{}, fmt.Errorf("GetGraphsByClientID: %w", err)
 	}
@@ -27,16 +45,17 @@ func (app *application) ensureTrainingGraphForRound(
 		}
 	}
 
+	// A placeholder has a zero EventAt, so CREATE_TRAINING_GRAPH can still fill in its configuration.
 	g := store.TrainingGraph{
 		ClientID:    clientID,
 		ServerRound: serverRound,
 	}
 
-	if err := app.store.TrainingGraphs.Create(ctx, g); err != nil {
+	if err := app.storage(ctx).TrainingGraphs.Create(ctx, g); err != nil {
 		return store.TrainingGraph{}, fmt.Errorf("Create training_graph: %w", err)
 	}
 
-	graphs, err = app.store.TrainingGraphs.GetGraphsByClientID(ctx, clientID)
+	graphs, err = app.storage(ctx).TrainingGraphs.GetGraphsByClientID(ctx, clientID)
 	if err != nil {
 		return store.TrainingGraph{}, fmt.Errorf("GetGraphsByClientID after create: %w", err)
 	}
@@ -56,29 +75,23 @@ func (app *application) handleCreateTrainingGraph(
 	p CreateTrainingGraphPayload,
 ) error {
 	// Ensure training exists.
-	if _, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID); err != nil {
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
 		return err
 	}
 
 	// Ensure client exists (state "train" for training events).
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		"train",
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "train")
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicates/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
-	// Create a training graph for this client and round with full configuration.
+	// Create a training graph for this client and round with full configuration,
+	// or fill in the placeholder created by an earlier epoch point.
 	g := store.TrainingGraph{
 		ClientID:     client.ID,
 		ServerRound:  p.ServerRound,
@@ -86,16 +99,16 @@ func (app *application) handleCreateTrainingGraph(
 		LearningRate: p.LearningRate,
 		NumEpochs:    p.NumEpochs,
 		BatchSize:    p.BatchSize,
+		EventAt:      env.Timestamp,
 	}
 
-	if err := app.store.TrainingGraphs.Create(ctx, g); err != nil {
+	if err := app.storage(ctx).TrainingGraphs.Create(ctx, g); err != nil {
 		return err
 	}
+	app.ensured.invalidateTrainingGraph(p.FLTrainingID, p.PartitionID, p.ServerRound)
 
 	// Update last_log_read marker.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
 
 // handleAddOneEpochTrainingGraphPoint adds a single epoch point into the training graph.
@@ -105,35 +118,28 @@ func (app *application) handleAddOneEpochTrainingGraphPoint(
 	p AddOneEpochTrainingGraphPointPayload,
 ) error {
 	// Ensure training exists.
-	if _, err := app.store.FLTrainings.Ensure(ctx, p.FLTrainingID); err != nil {
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
 		return err
 	}
 
 	// Ensure client exists.
-	client, err := app.store.FLTrainingClients.EnsureByFLTrainingIDAndPartitionID(
-		ctx,
-		p.FLTrainingID,
-		p.PartitionID,
-		env.NodeName,
-		env.PodName,
-		"train",
-	)
+	client, err := app.ensureClient(ctx, env, p.FLTrainingID, p.PartitionID, "train")
 	if err != nil {
 		return err
 	}
 
 	// Skip duplicates/out-of-order events.
-	if app.shouldSkipByLastLogRead(client, env.Timestamp, env.Event) {
+	if app.shouldSkipByLastLogRead(ctx, client, env.Timestamp, env.Event) {
 		return nil
 	}
 
 	// Ensure training graph exists for this server round.
-	graph, err := app.ensureTrainingGraphForRound(ctx, client.ID, p.ServerRound)
+	graph, err := app.ensureTrainingGraphForRound(ctx, client, p.ServerRound)
 	if err != nil {
 		return err
 	}
 
-	// Insert a new graph point.
+	// Insert or overwrite the point for this epoch.
 	point := store.TrainingGraphPoint{
 		GraphID:          graph.ID,
 		CurrentEpoch:     p.CurrentEpoch,
@@ -142,14 +148,13 @@ func (app *application) handleAddOneEpochTrainingGraphPoint(
 		ValLoss:          p.ValLoss,
 		Accuracy:         p.Accuracy,
 		EpochElapsedTime: p.EpochTrainingElapsed,
+		EventAt:          env.Timestamp,
 	}
 
-	if err := app.store.TrainingGraphs.CreatePoint(ctx, point); err != nil {
+	if err := app.storage(ctx).TrainingGraphs.CreatePoint(ctx, point); err != nil {
 		return err
 	}
 
 	// Update last_log_read.
-	app.updateClientLastLogRead(ctx, client, env.Timestamp)
-
-	return nil
+	return app.updateClientLastLogRead(ctx, client, env.Timestamp)
 }
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/weights"
+)
+
+// handleModelWeights stores the model weights of a server round in the blob store.
+func (app *application) handleModelWeights(
+	ctx context.Context,
+	env Envelope,
+	p ModelWeightsPayload,
+) error {
+	// Ensure training exists.
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
+		return err
+	}
+
+	// Ensure training server row exists for this training.
+	srv, err := app.storage(ctx).TrainingServers.EnsureByFLTrainingID(
+		ctx,
+		p.FLTrainingID,
+		env.NodeName,
+		env.PodName,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Skip duplicate/out-of-order events.
+	if app.shouldSkipByServerLastLogRead(ctx, srv, env.Timestamp, env.Event) {
+		return nil
+	}
+
+	if err := app.storeModelWeights(ctx, p.FLTrainingID, p.ServerRound, p.Layers); err != nil {
+		return err
+	}
+
+	// Update last_log_read marker for server.
+	return app.updateServerLastLogRead(ctx, srv, env.Timestamp)
+}
+
+// storeModelWeights parses the JSON layers of a server round and stores them
+// as a blob with their metadata in the DB.
+func (app *application) storeModelWeights(
+	ctx context.Context,
+	flTrainingID string,
+	serverRound int,
+	layersJSON []byte,
+) error {
+	layers, err := weights.ParseJSON(layersJSON)
+	if err != nil {
+		return fmt.Errorf("parse model weights: %w", err)
+	}
+
+	w, err := app.storage(ctx).FLModelWeights.Upsert(ctx, flTrainingID, serverRound, layers)
+	if err != nil {
+		return err
+	}
+
+	app.logger.Infow("stored model weights",
+		"fl_training_id", flTrainingID,
+		"server_round", serverRound,
+		"sha256", w.SHA256,
+		"layers", len(w.Layers),
+		"raw_size", w.RawSize,
+		"stored_size", w.StoredSize,
+	)
+
+	return nil
+}
+
+// handleCreateFLTraining is called when the server announces a new FL training
+// or updates its configuration (e.g. total number of rounds).
+func (app *application) handleCreateFLTraining(
+	ctx context.Context,
+	env Envelope,
+	p CreateFLTrainingPayload,
+) error {
+	// Ensure training exists.
+	tr, err := app.ensureTraining(ctx, p.FLTrainingID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure training server row exists.
+	srv, err := app.storage(ctx).TrainingServers.EnsureByFLTrainingID(
+		ctx,
+		p.FLTrainingID,
+		env.NodeName,
+		env.PodName,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Skip duplicate/out-of-order events.
+	if app.shouldSkipByServerLastLogRead(ctx, srv, env.Timestamp, env.Event) {
+		return nil
+	}
+
+	app.logger.Infow("create or update FL training from server",
+		"fl_training_id", tr.FLTrainingID,
+		"num_rounds", p.NumRounds,
+	)
+
+	// Update total number of server rounds based on server configuration.
+	if err := app.storage(ctx).FLTrainings.UpdateTotalServerRound(
+		ctx,
+		p.FLTrainingID,
+		p.NumRounds,
+	); err != nil {
+		return err
+	}
+	app.ensured.invalidateTraining(p.FLTrainingID)
+
+	// Update last_log_read marker for server.
+	return app.updateServerLastLogRead(ctx, srv, env.Timestamp)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Chunked transfers are reassembled in memory. BEGIN and CHUNK do not advance the server's
+// last_log_read, so the marker only moves once the verified weights are stored by END, and
+// the log cursor of the server does not move past an open transfer. A transfer re-read
+// after a restart is therefore reassembled again; if it was already stored, its END is
+// skipped as a duplicate and discards it.
+
+// handleModelWeightsBegin starts the reassembly of chunked model weights.
+func (app *application) handleModelWeightsBegin(
+	ctx context.Context,
+	env Envelope,
+	p ModelWeightsBeginPayload,
+) error {
+	// Ensure training exists.
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
+		return err
+	}
+
+	// Ensure training server row exists for this training.
+	srv, err := app.storage(ctx).TrainingServers.EnsureByFLTrainingID(
+		ctx,
+		p.FLTrainingID,
+		env.NodeName,
+		env.PodName,
+	)
+	if err != nil {
+		return err
+	}
+
+	// BEGIN is not skipped by last_log_read: other server events may have advanced it
+	// past a transfer that was still open when the service stopped. Such a transfer is
+	// collected again, but it is not new.
+	replayed := !isRedrive(ctx) && !env.Timestamp.After(srv.LastLogRead)
+	if replayed {
+		markSkipped(ctx)
+	}
+
+	key := weightsTransferKey{p.FLTrainingID, p.ServerRound, p.TransferID}
+	if err := app.weightsTransfers.begin(key, p.TotalChunks, logSource(ctx), replayed, time.Now()); err != nil {
+		return fmt.Errorf("model weights transfer %s: %w", p.TransferID, err)
+	}
+
+	app.logger.Infow("model weights transfer started",
+		"fl_training_id", p.FLTrainingID,
+		"server_round", p.ServerRound,
+		"transfer_id", p.TransferID,
+		"total_chunks", p.TotalChunks,
+	)
+
+	return nil
+}
+
+// handleModelWeightsChunk stores one chunk of a transfer. Chunks of an unknown transfer, which
+// was rejected or has timed out, are dropped.
+func (app *application) handleModelWeightsChunk(
+	ctx context.Context,
+	env Envelope,
+	p ModelWeightsChunkPayload,
+) error {
+	key := weightsTransferKey{p.FLTrainingID, p.ServerRound, p.TransferID}
+
+	fresh, err := app.weightsTransfers.addChunk(key, p.Seq, p.Data, time.Now())
+	if errors.Is(err, errUnknownWeightsTransfer) {
+		app.logger.Debugw("dropping chunk of unknown model weights transfer",
+			"fl_training_id", p.FLTrainingID,
+			"server_round", p.ServerRound,
+			"transfer_id", p.TransferID,
+			"seq", p.Seq,
+		)
+		markSkipped(ctx)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		markSkipped(ctx)
+	}
+
+	return nil
+}
+
+// handleModelWeightsEnd verifies the checksum of a complete transfer and stores the weights.
+func (app *application) handleModelWeightsEnd(
+	ctx context.Context,
+	env Envelope,
+	p ModelWeightsEndPayload,
+) error {
+	// Ensure training exists.
+	if _, err := app.ensureTraining(ctx, p.FLTrainingID); err != nil {
+		return err
+	}
+
+	// Ensure training server row exists for this training.
+	srv, err := app.storage(ctx).TrainingServers.EnsureByFLTrainingID(
+		ctx,
+		p.FLTrainingID,
+		env.NodeName,
+		env.PodName,
+	)
+	if err != nil {
+		return err
+	}
+
+	key := weightsTransferKey{p.FLTrainingID, p.ServerRound, p.TransferID}
+
+	// Skip duplicate/out-of-order events; a re-read transfer was already stored.
+	if app.shouldSkipByServerLastLogRead(ctx, srv, env.Timestamp, env.Event) {
+		app.weightsTransfers.drop(key)
+		return nil
+	}
+
+	// an END without a transfer, e.g. one that timed out or was rejected, fails so that
+	// the lost weights show up as a dead letter
+	data, err := app.weightsTransfers.assemble(key)
+	if err != nil {
+		return fmt.Errorf("model weights transfer %s: %w", p.TransferID, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != strings.ToLower(p.SHA256) {
+		return fmt.Errorf("model weights transfer %s: checksum mismatch: got %s, want %s", p.TransferID, got, p.SHA256)
+	}
+
+	if err := app.storeModelWeights(ctx, p.FLTrainingID, p.ServerRound, data); err != nil {
+		return err
+	}
+
+	// the chunks are kept until the weights committed, so a failed END can be re-driven
+	onCommit(ctx, func() { app.weightsTransfers.drop(key) })
+
+	// Update last_log_read marker for server.
+	return app.updateServerLastLogRead(ctx, srv, env.Timestamp)
+}
+
+// validateWeightsTransfer checks the fields every transfer event carries.
+func validateWeightsTransfer(flTrainingID, transferID string) error {
+	if err := validateFLTrainingID(flTrainingID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(transferID) == "" {
+		return errors.New("transfer_id is required")
+	}
+	return nil
+}
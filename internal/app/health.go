@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheckTimeout bounds each dependency check of a probe.
+const healthCheckTimeout = 2 * time.Second
+
+// pipelineHealth tracks whether the event consumer keeps up: the last time its loop
+// took an item off the events channel or ran a periodic flush.
+type pipelineHealth struct {
+	maxStall     time.Duration
+	lastActivity atomic.Int64 // unix nanoseconds
+	queued       func() int   // events waiting in the channel; nil until watchQueue
+	draining     atomic.Bool  // set once a shutdown starts
+}
+
+// newPipelineHealth returns a tracker that counts the start as activity, so a consumer
+// that has not run yet is not reported stalled right away.
+func newPipelineHealth(maxStall string) (*pipelineHealth, error) {
+	d, err := time.ParseDuration(maxStall)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipeline max stall: %w", err)
+	}
+
+	h := &pipelineHealth{maxStall: d}
+	h.touch()
+	return h, nil
+}
+
+// watchQueue makes check look at the depth of events.
+func (h *pipelineHealth) watchQueue(events chan queuedEvent) {
+	h.queued = func() int { return len(events) }
+}
+
+func (h *pipelineHealth) touch() {
+	h.lastActivity.Store(time.Now().UnixNano())
+}
+
+// check returns an error if events are waiting and the consumer made no progress within
+// maxStall. An empty queue is healthy however long the pods stay quiet.
+func (h *pipelineHealth) check(now time.Time) error {
+	if h.queued == nil {
+		return nil
+	}
+
+	queued := h.queued()
+	if queued == 0 {
+		return nil
+	}
+
+	stall := now.Sub(time.Unix(0, h.lastActivity.Load()))
+	if stall > h.maxStall {
+		return fmt.Errorf("%d events queued, no consumer progress for %s", queued, stall.Round(time.Second))
+	}
+	return nil
+}
+
+// healthResponse reports "ok" or the error of every check.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// healthzHandler is the liveness probe. It fails only if the event consumer is wedged
+// with events waiting, which a restart fixes; unavailable dependencies are left to the
+// readiness probe.
+func (app *application) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeHealth(w, map[string]error{
+		"pipeline": app.pipelineHealth.check(time.Now()),
+	})
+}
+
+// readyzHandler is the readiness probe: Postgres answers a ping, the Kubernetes API
+// answers a discovery call and the event consumer keeps up.
+// It fails as soon as a shutdown starts.
+func (app *application) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(context.Context) error{
+		"db":   app.store.Ping,
+		"kube": app.checkKubeAPI,
+		"pipeline": func(context.Context) error {
+			if app.pipelineHealth.draining.Load() {
+				return errors.New("shutting down")
+			}
+			return app.pipelineHealth.check(time.Now())
+		},
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			defer cancel()
+
+			err := check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	app.writeHealth(w, results)
+}
+
+// checkKubeAPI asks the API server for its version.
+func (app *application) checkKubeAPI(ctx context.Context) error {
+	return app.kube.DiscoveryInterface.RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+func (app *application) writeHealth(w http.ResponseWriter, results map[string]error) {
+	resp := healthResponse{Status: "ok", Checks: make(map[string]string, len(results))}
+	status := http.StatusOK
+
+	for name, err := range results {
+		if err != nil {
+			resp.Checks[name] = err.Error()
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[name] = "ok"
+	}
+
+	if err := writeJSON(w, status, resp); err != nil {
+		app.logger.Errorw("failed to write health response", "error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPipelineHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		queued  int
+		stall   time.Duration
+		wantErr bool
+	}{
+		{name: "empty queue, idle consumer", queued: 0, stall: time.Hour},
+		{name: "queued, recent progress", queued: 5, stall: time.Second},
+		{name: "queued, stalled consumer", queued: 5, stall: 3 * time.Minute, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := newPipelineHealth("2m")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events := make(chan queuedEvent, 10)
+			for range tt.queued {
+				events <- queuedEvent{}
+			}
+			h.watchQueue(events)
+
+			err = h.check(time.Now().Add(tt.stall))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultClientLogLimit = 100
+	maxClientLogLimit     = 1000
+)
+
+// clientLogsResponse is one page of client log lines.
+// NextCursor is empty when there are no more lines in the direction of the query.
+type clientLogsResponse struct {
+	Logs       []store.ClientLog `json:"logs"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// listClientLogsHandler returns the log lines of a client, oldest first.
+//
+// Query parameters:
+//   - since, until: RFC 3339 bounds on client_output_at (since inclusive, until exclusive)
+//   - q: text to look for; search=fulltext matches it as a full-text query instead of a substring
+//   - limit: page size, up to maxClientLogLimit
+//   - tail=N: the last N lines; next_cursor then pages towards older lines
+//   - cursor: next_cursor of the previous page
+func (app *application) listClientLogsHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.clientFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	query, err := clientLogQueryFromRequest(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	query.ClientID = client.ID
+
+	// One extra line tells whether another page follows.
+	limit := query.Limit
+	query.Limit++
+
+	logs, err := app.store.ClientLogs.Query(r.Context(), query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	resp := clientLogsResponse{Logs: logs}
+	if len(logs) > limit {
+		if query.Tail {
+			// The page is returned oldest first, so the extra line is at the front.
+			resp.Logs = logs[1:]
+			resp.NextCursor = encodeClientLogCursor(resp.Logs[0])
+		} else {
+			resp.Logs = logs[:limit]
+			resp.NextCursor = encodeClientLogCursor(resp.Logs[limit-1])
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func clientLogQueryFromRequest(r *http.Request) (store.ClientLogQuery, error) {
+	q := r.URL.Query()
+
+	query := store.ClientLogQuery{
+		Search: q.Get("q"),
+		Limit:  defaultClientLogLimit,
+	}
+
+	var err error
+	if query.Since, err = timeQueryParam(r, "since"); err != nil {
+		return store.ClientLogQuery{}, err
+	}
+	if query.Until, err = timeQueryParam(r, "until"); err != nil {
+		return store.ClientLogQuery{}, err
+	}
+
+	switch q.Get("search") {
+	case "", "substring":
+	case "fulltext":
+		query.FullText = true
+	default:
+		return store.ClientLogQuery{}, fmt.Errorf("search must be substring or fulltext")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxClientLogLimit {
+			return store.ClientLogQuery{}, fmt.Errorf("limit must be between 1 and %d", maxClientLogLimit)
+		}
+		query.Limit = limit
+	}
+
+	if v := q.Get("tail"); v != "" {
+		tail, err := strconv.Atoi(v)
+		if err != nil || tail < 1 || tail > maxClientLogLimit {
+			return store.ClientLogQuery{}, fmt.Errorf("tail must be between 1 and %d", maxClientLogLimit)
+		}
+		query.Tail = true
+		query.Limit = tail
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodeClientLogCursor(v)
+		if err != nil {
+			return store.ClientLogQuery{}, err
+		}
+		query.Cursor = &cursor
+	}
+
+	return query, nil
+}
+
+// timeQueryParam parses an optional RFC 3339 query parameter. It returns nil if the parameter is absent.
+func timeQueryParam(r *http.Request, name string) (*time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return &t, nil
+}
+
+// encodeClientLogCursor returns the opaque page cursor pointing at l.
+func encodeClientLogCursor(l store.ClientLog) string {
+	raw := l.ClientOutputAt.UTC().Format(time.RFC3339Nano) + "," + l.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeClientLogCursor(s string) (store.ClientLogCursor, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return store.ClientLogCursor{}, errInvalid
+	}
+
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return store.ClientLogCursor{}, errInvalid
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return store.ClientLogCursor{}, errInvalid
+	}
+
+	logID, err := uuid.Parse(id)
+	if err != nil {
+		return store.ClientLogCursor{}, errInvalid
+	}
+
+	return store.ClientLogCursor{ClientOutputAt: t, ID: logID}, nil
+}
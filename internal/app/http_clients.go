@@ -0,0 +1,123 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// trainingGraphResponse is a training graph together with all of its epoch points.
+type trainingGraphResponse struct {
+	store.TrainingGraph
+	Points []store.TrainingGraphPoint `json:"points"`
+}
+
+// testingGraphResponse is the testing graph of a client together with its server-round points.
+type testingGraphResponse struct {
+	store.TestingGraph
+	Points []store.TestingGraphPoint `json:"points"`
+}
+
+// getClientHandler returns a single training client by its row ID.
+func (app *application) getClientHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.clientFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, client); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listClientTrainingGraphsHandler returns every training graph of a client (one per server round)
+// with its points.
+func (app *application) listClientTrainingGraphsHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.clientFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	graphs, err := app.store.TrainingGraphs.GetGraphsByClientID(ctx, client.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	resp := make([]trainingGraphResponse, 0, len(graphs))
+	for _, g := range graphs {
+		points, err := app.store.TrainingGraphs.GetPointsByGraphID(ctx, g.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		resp = append(resp, trainingGraphResponse{TrainingGraph: g, Points: points})
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getClientTestingGraphHandler returns the single testing graph of a client with its points.
+func (app *application) getClientTestingGraphHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.clientFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	graphs, err := app.store.TestingGraphs.GetGraphsByClientID(ctx, client.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if len(graphs) == 0 {
+		app.notFoundResponse(w, r, fmt.Errorf("no testing graph for client %s", client.ID))
+		return
+	}
+
+	points, err := app.store.TestingGraphs.GetPointsByGraphID(ctx, graphs[0].ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	resp := testingGraphResponse{TestingGraph: graphs[0], Points: points}
+
+	if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// clientFromRequest loads the client referenced by the {clientID} URL parameter.
+// It writes the error response itself and reports false if the client cannot be loaded.
+func (app *application) clientFromRequest(w http.ResponseWriter, r *http.Request) (store.FLTrainingClient, bool) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "clientID"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid client id: %w", err))
+		return store.FLTrainingClient{}, false
+	}
+
+	client, err := app.store.FLTrainingClients.GetByID(r.Context(), clientID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return store.FLTrainingClient{}, false
+	}
+
+	return client, true
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeadLetterLimit = 100
+	maxDeadLetterLimit     = 1000
+
+	// A batch re-drive handles its dead letters one by one within the request timeout.
+	defaultRedriveLimit = 50
+	maxRedriveLimit     = 200
+)
+
+// redriveResult summarizes a batch re-drive of dead letters.
+type redriveResult struct {
+	Attempted int                   `json:"attempted"`
+	Resolved  int                   `json:"resolved"`
+	Failed    []redriveFailedResult `json:"failed"`
+}
+
+type redriveFailedResult struct {
+	ID    uuid.UUID `json:"id"`
+	Error string    `json:"error"`
+}
+
+// listDeadLettersHandler returns dead letters filtered by ?stage, ?event and ?include_resolved.
+func (app *application) listDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := deadLetterFilterFromRequest(r, defaultDeadLetterLimit, maxDeadLetterLimit)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	deadLetters, err := app.store.DeadLetterEvents.List(r.Context(), filter)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, deadLetters); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDeadLetterHandler returns a single dead letter.
+func (app *application) getDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	dl, ok := app.deadLetterFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dl); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// redriveDeadLetterHandler handles a single dead letter again.
+func (app *application) redriveDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	dl, ok := app.deadLetterFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.redriveDeadLetter(r.Context(), dl); err != nil {
+		switch {
+		case errors.Is(err, errDeadLetterResolved), errors.Is(err, errDeadLetterNotRedrivable):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		}
+		return
+	}
+
+	dl, err := app.store.DeadLetterEvents.GetByID(r.Context(), dl.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dl); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// redriveDeadLettersHandler handles up to ?limit unresolved dead letters matching the request
+// filters again. It stops early once the request is canceled or times out; the dead letters
+// it did not attempt are left for the next call.
+func (app *application) redriveDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := deadLetterFilterFromRequest(r, defaultRedriveLimit, maxRedriveLimit)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	filter.IncludeResolved = false
+
+	deadLetters, err := app.store.DeadLetterEvents.List(r.Context(), filter)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	result := redriveResult{Failed: []redriveFailedResult{}}
+	for _, dl := range deadLetters {
+		if r.Context().Err() != nil {
+			break
+		}
+		if dl.Stage == store.DeadLetterStageTruncated {
+			continue
+		}
+		result.Attempted++
+
+		if err := app.redriveDeadLetter(r.Context(), dl); err != nil {
+			result.Failed = append(result.Failed, redriveFailedResult{ID: dl.ID, Error: err.Error()})
+			continue
+		}
+		result.Resolved++
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, result); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) deadLetterFromRequest(w http.ResponseWriter, r *http.Request) (store.DeadLetterEvent, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "deadLetterID"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid dead letter id: %w", err))
+		return store.DeadLetterEvent{}, false
+	}
+
+	dl, err := app.store.DeadLetterEvents.GetByID(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return store.DeadLetterEvent{}, false
+	}
+
+	return dl, true
+}
+
+// deadLetterFilterFromRequest reads the dead letter filters of r; ?limit defaults to
+// defaultLimit and may be at most maxLimit.
+func deadLetterFilterFromRequest(r *http.Request, defaultLimit, maxLimit int) (store.DeadLetterFilter, error) {
+	q := r.URL.Query()
+
+	filter := store.DeadLetterFilter{
+		Stage: q.Get("stage"),
+		Event: q.Get("event"),
+		Limit: defaultLimit,
+	}
+
+	switch filter.Stage {
+	case "", store.DeadLetterStageParse, store.DeadLetterStageHandle, store.DeadLetterStageTruncated:
+	default:
+		return store.DeadLetterFilter{}, fmt.Errorf("invalid stage %q", filter.Stage)
+	}
+
+	if v := q.Get("include_resolved"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return store.DeadLetterFilter{}, fmt.Errorf("invalid include_resolved: %w", err)
+		}
+		filter.IncludeResolved = b
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return store.DeadLetterFilter{}, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,12 @@
+package app
+
+import (
+	"net/http"
+)
+
+// listEventsHandler returns every event type the ingester knows how to handle.
+func (app *application) listEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, app.registry.List()); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/export"
+	"github.com/go-chi/chi/v5"
+)
+
+// exportTrainingPointsHandler downloads every epoch point of a training joined with its
+// client and graph configuration, as ?format=csv (default) or ?format=parquet.
+func (app *application) exportTrainingPointsHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID, format, ok := app.exportRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := app.store.TrainingGraphs.GetExportRowsByFLTrainingID(r.Context(), flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	setExportHeaders(w, format, flTrainingID+"_training_points")
+	if err := export.WriteTrainingPoints(w, format, rows); err != nil {
+		app.logger.Errorw("failed to write export", "fl_training_id", flTrainingID, "error", err)
+	}
+}
+
+// exportTestingPointsHandler downloads every testing point of a training joined with its client,
+// as ?format=csv (default) or ?format=parquet.
+func (app *application) exportTestingPointsHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID, format, ok := app.exportRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := app.store.TestingGraphs.GetExportRowsByFLTrainingID(r.Context(), flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	setExportHeaders(w, format, flTrainingID+"_testing_points")
+	if err := export.WriteTestingPoints(w, format, rows); err != nil {
+		app.logger.Errorw("failed to write export", "fl_training_id", flTrainingID, "error", err)
+	}
+}
+
+// exportTensorBoardHandler downloads the curves of a training as a TensorBoard event file.
+// Saved into a directory of a TensorBoard logdir, it shows up as one run. Every client has
+// the per-epoch scalars client/{partition}/train_loss, val_loss, accuracy and
+// epoch_elapsed_time, and the per-round scalars client/{partition}/test_loss and
+// test_accuracy; the test accuracy is renamed so it does not mix with the epoch accuracy.
+func (app *application) exportTensorBoardHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+	if !app.exportTrainingExists(w, r, flTrainingID) {
+		return
+	}
+
+	ctx := r.Context()
+
+	trainingRows, err := app.store.TrainingGraphs.GetExportRowsByFLTrainingID(ctx, flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	testingRows, err := app.store.TestingGraphs.GetExportRowsByFLTrainingID(ctx, flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.TensorBoardFileName(time.Now(), flTrainingID)))
+	if err := export.WriteTensorBoard(w, trainingRows, testingRows); err != nil {
+		app.logger.Errorw("failed to write export", "fl_training_id", flTrainingID, "error", err)
+	}
+}
+
+// exportRequest validates the training and format of an export request.
+// It writes the error response itself and reports false if the request is invalid.
+func (app *application) exportRequest(w http.ResponseWriter, r *http.Request) (string, export.Format, bool) {
+	format := export.FormatCSV
+	if v := r.URL.Query().Get("format"); v != "" {
+		f, err := export.ParseFormat(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return "", "", false
+		}
+		format = f
+	}
+
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+	if !app.exportTrainingExists(w, r, flTrainingID) {
+		return "", "", false
+	}
+
+	return flTrainingID, format, true
+}
+
+// exportTrainingExists writes a not found response and reports false if the training does not exist.
+func (app *application) exportTrainingExists(w http.ResponseWriter, r *http.Request, flTrainingID string) bool {
+	if _, err := app.store.FLTrainings.GetByFLTrainingID(r.Context(), flTrainingID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return false
+	}
+
+	return true
+}
+
+func setExportHeaders(w http.ResponseWriter, format export.Format, name string) {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+string(format)))
+}
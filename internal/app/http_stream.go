@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// streamHeartbeatInterval keeps idle SSE connections alive through proxies.
+const streamHeartbeatInterval = 15 * time.Second
+
+// streamEvent is the SSE data of a single envelope, including the Kubernetes metadata
+// that Envelope itself does not serialize.
+type streamEvent struct {
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	PodName   string          `json:"pod_name"`
+	NodeName  string          `json:"node_name"`
+	Component string          `json:"component"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// streamTrainingEventsHandler pushes every handled envelope of a training to the
+// client as Server-Sent Events until the client disconnects.
+func (app *application) streamTrainingEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server's WriteTimeout, so lift the deadline for this response.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.internalServerError(w, r, fmt.Errorf("streaming unsupported: %w", err))
+		return
+	}
+
+	events, unsubscribe := app.broadcaster.subscribe(flTrainingID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		app.logger.Warnw("sse flush failed", "fl_training_id", flTrainingID, "error", err)
+		return
+	}
+
+	app.logger.Infow("sse client connected", "fl_training_id", flTrainingID, "remote", r.RemoteAddr)
+	defer app.logger.Infow("sse client disconnected", "fl_training_id", flTrainingID, "remote", r.RemoteAddr)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case env, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(streamEvent{
+				Event:     env.Event,
+				Payload:   env.Payload,
+				PodName:   env.PodName,
+				NodeName:  env.NodeName,
+				Component: env.Component,
+				Timestamp: env.Timestamp,
+			})
+			if err != nil {
+				app.logger.Warnw("failed to marshal sse event", "event", env.Event, "error", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", env.Event, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
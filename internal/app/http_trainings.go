@@ -0,0 +1,67 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// listTrainingsHandler returns every known FL training.
+func (app *application) listTrainingsHandler(w http.ResponseWriter, r *http.Request) {
+	trainings, err := app.store.FLTrainings.GetAll(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, trainings); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getTrainingHandler returns a single FL training by its fl_training_id.
+func (app *application) getTrainingHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+
+	training, err := app.store.FLTrainings.GetByFLTrainingID(r.Context(), flTrainingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, training); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listTrainingClientsHandler returns all clients that joined a training, ordered by partition.
+func (app *application) listTrainingClientsHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+
+	if _, err := app.store.FLTrainings.GetByFLTrainingID(r.Context(), flTrainingID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	clients, err := app.store.FLTrainingClients.GetByFLTrainingID(r.Context(), flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, clients); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/weights"
+	"github.com/go-chi/chi/v5"
+)
+
+// modelWeightsLayer is one layer of a JSON weights download; Values are flattened in row-major order.
+type modelWeightsLayer struct {
+	Name   string    `json:"name,omitempty"`
+	Shape  []int     `json:"shape"`
+	Values []float64 `json:"values"`
+}
+
+type modelWeightsResponse struct {
+	store.FLModelWeights
+	Values []modelWeightsLayer `json:"values"`
+}
+
+type modelWeightsStatsResponse struct {
+	store.FLModelWeights
+	Stats []weights.LayerStats `json:"stats"`
+}
+
+type modelWeightsDiffResponse struct {
+	FLTrainingID string              `json:"fl_training_id"`
+	FromRound    int                 `json:"from_round"`
+	ToRound      int                 `json:"to_round"`
+	Layers       []weights.LayerDiff `json:"layers"`
+}
+
+// listModelWeightsHandler returns the weights metadata of every round of a training that has weights.
+func (app *application) listModelWeightsHandler(w http.ResponseWriter, r *http.Request) {
+	flTrainingID := chi.URLParam(r, "flTrainingID")
+	if !app.exportTrainingExists(w, r, flTrainingID) {
+		return
+	}
+
+	list, err := app.store.FLModelWeights.ListByFLTrainingID(r.Context(), flTrainingID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if list == nil {
+		list = []store.FLModelWeights{}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, list); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// downloadModelWeightsHandler downloads the weights of a round, as ?format=bin (default),
+// the little-endian values of every layer one after the other in the dtype of the weights,
+// or as ?format=json.
+func (app *application) downloadModelWeightsHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "bin"
+	}
+	if format != "bin" && format != "json" {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid format %q, expected bin or json", format))
+		return
+	}
+
+	mw, ok := app.modelWeightsFromRequest(w, r, chi.URLParam(r, "serverRound"))
+	if !ok {
+		return
+	}
+
+	layers, err := app.store.FLModelWeights.Load(r.Context(), mw)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if format == "json" {
+		resp := modelWeightsResponse{FLModelWeights: mw, Values: make([]modelWeightsLayer, len(layers))}
+		for i, l := range layers {
+			resp.Values[i] = modelWeightsLayer{Name: l.Name, Shape: l.Shape, Values: l.Values}
+		}
+
+		if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// rows from before blobs have no dtype
+	dtype := mw.DType
+	if dtype == "" {
+		dtype = weights.DTypeOf(layers)
+	}
+	raw, err := weights.Encode(layers, dtype)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// the layer names and shapes needed to split the values are in the metadata
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q",
+		fmt.Sprintf("%s_round_%d.%s", mw.FLTrainingID, mw.ServerRound, dtype)))
+	w.Header().Set("X-Weights-DType", dtype)
+	if mw.SHA256 != "" {
+		w.Header().Set("X-Weights-SHA256", mw.SHA256)
+	}
+	if _, err := w.Write(raw); err != nil {
+		app.logger.Errorw("failed to write model weights", "fl_training_id", mw.FLTrainingID, "error", err)
+	}
+}
+
+// getModelWeightsStatsHandler returns the L2 norm, mean, std, min and max of every layer of a round.
+func (app *application) getModelWeightsStatsHandler(w http.ResponseWriter, r *http.Request) {
+	mw, ok := app.modelWeightsFromRequest(w, r, chi.URLParam(r, "serverRound"))
+	if !ok {
+		return
+	}
+
+	layers, err := app.store.FLModelWeights.Load(r.Context(), mw)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	resp := modelWeightsStatsResponse{FLModelWeights: mw, Stats: weights.Stats(layers)}
+	if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// diffModelWeightsHandler compares the weights of ?from and ?to rounds layer by layer:
+// how far each layer moved and in which direction.
+func (app *application) diffModelWeightsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("from") == "" || q.Get("to") == "" {
+		app.badRequestResponse(w, r, errors.New("from and to rounds are required"))
+		return
+	}
+
+	from, ok := app.modelWeightsFromRequest(w, r, q.Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := app.modelWeightsFromRequest(w, r, q.Get("to"))
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	fromLayers, err := app.store.FLModelWeights.Load(ctx, from)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	toLayers, err := app.store.FLModelWeights.Load(ctx, to)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	diffs, err := weights.Diff(fromLayers, toLayers)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, fmt.Sprintf("rounds are not comparable: %s", err))
+		return
+	}
+
+	resp := modelWeightsDiffResponse{
+		FLTrainingID: from.FLTrainingID,
+		FromRound:    from.ServerRound,
+		ToRound:      to.ServerRound,
+		Layers:       diffs,
+	}
+	if err := app.jsonResponse(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// modelWeightsFromRequest loads the weights metadata of the given round of the request's training.
+// It writes the error response itself and reports false if the round is invalid or has no weights.
+func (app *application) modelWeightsFromRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	serverRound string,
+) (store.FLModelWeights, bool) {
+	round, err := strconv.Atoi(serverRound)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid server round %q", serverRound))
+		return store.FLModelWeights{}, false
+	}
+
+	mw, err := app.store.FLModelWeights.GetByRound(r.Context(), chi.URLParam(r, "flTrainingID"), round)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return store.FLModelWeights{}, false
+	}
+
+	return mw, true
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngestSource is a log file, or stdin, and the pod its lines were written by.
+type IngestSource struct {
+	Name      string // shown in logs, e.g. the file path
+	Reader    io.Reader
+	PodName   string
+	NodeName  string
+	Component string
+	// BaseTime stamps the lines that carry no timestamp: line n gets BaseTime plus n-1
+	// microseconds, so the same file always yields the same timestamps. If it is zero,
+	// a line without a timestamp is an error.
+	BaseTime time.Time
+}
+
+// RunIngest handles the events in the log lines of every source like the API does for
+// live pods, then writes the pending READLINE batches. Lines are either in the
+// `kubectl logs --timestamps` form, "<RFC3339 timestamp> <message>", or plain JSON stamped
+// from the BaseTime of their source, so that ingesting a file again skips its duplicates.
+//
+// If ctx is canceled, reading stops and the events already read are still handled.
+// The configuration is read from the same environment variables as the API.
+func RunIngest(ctx context.Context, sources []IngestSource, opts ...Option) error {
+	cfg := configFromEnv()
+
+	logger := newLogger(cfg.env)
+	defer logger.Sync()
+
+	app, closeApp, err := newApplication(cfg, logger, applyOptions(opts))
+	if err != nil {
+		return err
+	}
+	defer closeApp()
+
+	events := make(chan queuedEvent, 1000)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		readErr <- app.ingestSources(ctx, sources, events)
+	}()
+
+	// nothing abandons events here; every event read is handled and its READLINE rows written
+	app.consumeEvents(events, nil)
+
+	return <-readErr
+}
+
+func (app *application) ingestSources(ctx context.Context, sources []IngestSource, out chan<- queuedEvent) error {
+	for _, src := range sources {
+		lines, sent, err := app.ingestSource(ctx, src, out)
+
+		app.logger.Infow("read log source",
+			"source", src.Name,
+			"pod", src.PodName,
+			"lines", lines,
+			"events", sent,
+		)
+
+		if err != nil {
+			return fmt.Errorf("read %s: %w", src.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ingestSource sends the events in the lines of src to out. It returns the number of lines
+// read and events sent.
+func (app *application) ingestSource(ctx context.Context, src IngestSource, out chan<- queuedEvent) (int, int, error) {
+	// envelopeFromLogMessage and the dead letters take the metadata from a pod
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   src.PodName,
+			Labels: map[string]string{componentLabel: src.Component},
+		},
+		Spec: corev1.PodSpec{NodeName: src.NodeName},
+	}
+
+	reader := newLogLineReader(src.Reader, app.config.logPuller.maxLineSize)
+	lines, sent := 0, 0
+
+	for {
+		line, truncated, err := reader.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return lines, sent, nil
+			}
+			return lines, sent, err
+		}
+		lines++
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		ts, msg, err := parseK8sTimestampLine(line)
+		if err != nil {
+			// plain JSON lines carry no timestamp
+			if src.BaseTime.IsZero() {
+				return lines, sent, fmt.Errorf("line %d has no timestamp and no base time is set", lines)
+			}
+			ts, msg = src.BaseTime.Add(time.Duration(lines-1)*time.Microsecond), line
+		}
+
+		if truncated {
+			app.logger.Warnw("skipped oversized log line",
+				"source", src.Name,
+				"line", lines,
+				"maxLineSize", app.config.logPuller.maxLineSize,
+			)
+			app.recordTruncatedLine(ctx, pod, ts, msg)
+			continue
+		}
+
+		env, ok := app.envelopeFromLogMessage(ctx, pod, ts, msg)
+		if !ok {
+			continue
+		}
+
+		select {
+		case out <- queuedEvent{env: &env}:
+			sent++
+		case <-ctx.Done():
+			return lines, sent, ctx.Err()
+		}
+	}
+}
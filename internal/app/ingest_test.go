@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestIngestSourceStampsPlainJSONLines(t *testing.T) {
+	app := &application{logger: zap.NewNop().Sugar()}
+	app.config.logPuller.maxLineSize = 1024
+
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := IngestSource{
+		Name:      "test",
+		Reader:    strings.NewReader("{\"event\":\"A\"}\n{\"event\":\"B\"}\n2025-03-02T00:00:00Z {\"event\":\"C\"}\n"),
+		PodName:   "client-0",
+		Component: "clientapp",
+		BaseTime:  base,
+	}
+
+	out := make(chan queuedEvent, 3)
+	lines, sent, err := app.ingestSource(context.Background(), src, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines != 3 || sent != 3 {
+		t.Fatalf("lines, sent = %d, %d; want 3, 3", lines, sent)
+	}
+
+	want := []time.Time{
+		base,
+		base.Add(time.Microsecond),
+		time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		got := (<-out).env.Timestamp
+		if !got.Equal(w) {
+			t.Errorf("line %d: timestamp = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestIngestSourceRejectsUnstampedLineWithoutBaseTime(t *testing.T) {
+	app := &application{logger: zap.NewNop().Sugar()}
+	app.config.logPuller.maxLineSize = 1024
+
+	src := IngestSource{
+		Name:      "test",
+		Reader:    strings.NewReader("2025-03-02T00:00:00Z {\"event\":\"A\"}\n\n{\"event\":\"B\"}\n"),
+		PodName:   "client-0",
+		Component: "clientapp",
+	}
+
+	out := make(chan queuedEvent, 2)
+	lines, sent, err := app.ingestSource(context.Background(), src, out)
+	if err == nil {
+		t.Fatal("expected an error for a line without a timestamp")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error %q does not name line 3", err)
+	}
+	if lines != 3 || sent != 1 {
+		t.Errorf("lines, sent = %d, %d; want 3, 1", lines, sent)
+	}
+}
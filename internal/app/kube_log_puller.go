@@ -0,0 +1,403 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// followMinBackoff and followMaxBackoff bound the wait before a follower
+	// reopens a log stream that ended or failed.
+	followMinBackoff = 500 * time.Millisecond
+	followMaxBackoff = 30 * time.Second
+)
+
+// runKubeLogPuller watches matching pods through a shared informer and keeps one
+// long-lived log follower per pod until ctx is canceled.
+func (app *application) runKubeLogPuller(
+	ctx context.Context,
+	out chan<- queuedEvent,
+) {
+	namespace := app.config.podFilter.namespace
+	labelSelector := app.config.podFilter.labelSelector
+
+	followers := newPodFollowers()
+	defer followers.stopAll()
+
+	limiter := newStreamLimiter(app.config.logPuller.concurrency)
+
+	follow := func(ctx context.Context, pod corev1.Pod) {
+		app.followPodLogs(ctx, pod, namespace, limiter, out)
+	}
+
+	startIfReady := func(pod *corev1.Pod) {
+		if !podLogsAvailable(pod) {
+			return
+		}
+		if followers.start(ctx, pod, follow) {
+			app.logger.Infow("starting pod log follower",
+				"pod", pod.Name,
+				"node", pod.Spec.NodeName,
+			)
+		}
+	}
+
+	lw := cache.NewFilteredListWatchFromClient(
+		app.kube.CoreV1Interface.RESTClient(),
+		"pods",
+		namespace,
+		func(opts *metav1.ListOptions) {
+			opts.LabelSelector = labelSelector
+		},
+	)
+
+	informer := cache.NewSharedIndexInformer(lw, &corev1.Pod{}, 0, cache.Indexers{})
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				startIfReady(pod)
+			}
+		},
+		UpdateFunc: func(_, newObj any) {
+			// a pod is usually added while Pending; its follower starts once containers run
+			if pod, ok := newObj.(*corev1.Pod); ok {
+				startIfReady(pod)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+			if followers.stop(pod.UID) {
+				app.logger.Infow("stopped pod log follower for deleted pod", "pod", pod.Name)
+			}
+			app.metrics.forgetPod(pod.Name)
+			app.cursors.forget(string(pod.UID))
+		},
+	})
+	if err != nil {
+		app.logger.Errorw("failed to register pod event handler", "error", err)
+		return
+	}
+
+	app.logger.Infow("starting pod informer",
+		"namespace", namespace,
+		"labelSelector", labelSelector,
+	)
+
+	go informer.RunWithContext(ctx)
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		app.logger.Info("log puller context canceled before pod cache synced")
+		return
+	}
+
+	<-ctx.Done()
+	app.logger.Info("log puller context canceled")
+}
+
+// podLogsAvailable reports whether the pod's containers have started, i.e. whether
+// a log stream can be opened for it.
+func podLogsAvailable(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// followPodLogs streams a single pod's logs with Follow enabled. Whenever the stream
+// ends or fails it is reopened with backoff, resuming after the last seen line.
+// The starting position is seeded from the persisted log cursor of the pod.
+func (app *application) followPodLogs(
+	ctx context.Context,
+	pod corev1.Pod,
+	namespace string,
+	limiter *streamLimiter,
+	out chan<- queuedEvent,
+) {
+	container := defaultContainerName(pod)
+	cursor := app.loadLogCursor(ctx, pod, container)
+	backoff := followMinBackoff
+
+	if !cursor.LastTimestamp.IsZero() {
+		app.logger.Infow("resuming pod logs from persisted cursor",
+			"pod", pod.Name,
+			"container", container,
+			"since", cursor.LastTimestamp,
+		)
+	}
+
+	for {
+		newCursor, err := app.kubeLogPull(ctx, pod, namespace, limiter, cursor, out)
+		if ctx.Err() != nil {
+			app.logger.Infow("pod log follower stopped", "pod", pod.Name)
+			return
+		}
+
+		// Progress means the stream was healthy, so the next reconnect can be quick.
+		if newCursor != cursor {
+			cursor = newCursor
+			backoff = followMinBackoff
+		}
+
+		if err != nil {
+			app.logger.Infow("error while following pod logs (will reconnect)",
+				"pod", pod.Name,
+				"error", err,
+				"backoff", backoff,
+			)
+		} else {
+			app.logger.Debugw("pod log stream closed (will reconnect)",
+				"pod", pod.Name,
+				"since", cursor.LastTimestamp,
+				"backoff", backoff,
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			app.logger.Infow("pod log follower stopped", "pod", pod.Name)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, followMaxBackoff)
+	}
+}
+
+// kubeLogPull reads one log stream of the cursor's container until it ends, sending every
+// event line to out together with the cursor just past it. It returns the cursor of the
+// last line read, which the follower resumes from; the persisted cursor is only advanced
+// by the consumer.
+func (app *application) kubeLogPull(
+	ctx context.Context,
+	pod corev1.Pod,
+	namespace string,
+	limiter *streamLimiter,
+	cursor store.LogPullerCursor,
+	out chan<- queuedEvent,
+) (store.LogPullerCursor, error) {
+	podName := pod.Name
+	since := cursor.LastTimestamp
+
+	// set log options; Follow keeps the stream open and delivers new lines as they are written
+	opts := corev1.PodLogOptions{
+		Container:  cursor.Container,
+		Timestamps: true,
+		Follow:     true,
+	}
+
+	// without a cursor `since` is zero so it will send all the logs
+	// otherwise, we will only pull the log from `since`
+	if !since.IsZero() {
+		t := metav1.NewTime(since)
+		opts.SinceTime = &t
+	}
+
+	// setup connection
+	podLogsConnection := app.kube.Pods(namespace).GetLogs(podName, &opts)
+
+	// Only opening the stream takes a slot of limiter. A followed stream stays open for the
+	// pod's lifetime, so holding the slot while reading would starve every pod beyond the limit.
+	if !limiter.tryAcquire() {
+		app.logger.Warnw("log stream open limit reached, waiting for a free slot",
+			"pod", podName,
+			"concurrency", app.config.logPuller.concurrency,
+		)
+		if err := limiter.acquire(ctx); err != nil {
+			return cursor, err
+		}
+	}
+	logStream, err := podLogsConnection.Stream(ctx)
+	limiter.release()
+	if err != nil {
+		app.logger.Errorw("failed to open pod log stream", "pod", podName, "error", err)
+		return cursor, err
+	}
+	defer logStream.Close()
+
+	reader := newLogLineReader(logStream, app.config.logPuller.maxLineSize)
+	resume := newResumeFilter(cursor)
+
+	// pending is set while the cursor is past lines that were not queued yet
+	pending := false
+	queue := func(env *Envelope) error {
+		c := cursor
+		// a follower may block here while the consumer is busy; do not outlive ctx
+		select {
+		case out <- queuedEvent{env: env, cursor: &c}:
+			pending = false
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		line, truncated, err := reader.next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return cursor, ctx.Err()
+			}
+			if pending {
+				if err := queue(nil); err != nil {
+					return cursor, err
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return cursor, nil
+			}
+			app.logger.Errorw("read log error", "pod", podName, "err", err)
+			return cursor, err
+		}
+		app.metrics.linesRead.WithLabelValues(podName).Inc()
+
+		// prevents canceled when reading
+		select {
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		default:
+		}
+
+		app.logger.Debugw("logging raw pod line",
+			"pod", podName,
+			"line", line,
+		)
+
+		ts, msg, timestampParseErr := parseK8sTimestampLine(line)
+		if timestampParseErr != nil {
+			continue
+		}
+
+		lineHash := hashLogLine(line)
+		if resume.skip(ts, lineHash) {
+			continue
+		}
+
+		var env *Envelope
+		if truncated {
+			// the event in this line is lost; record where and move past it
+			app.logger.Warnw("skipped oversized log line",
+				"pod", podName,
+				"ts", ts,
+				"maxLineSize", app.config.logPuller.maxLineSize,
+			)
+			app.metrics.truncatedLines.WithLabelValues(podName).Inc()
+			app.recordTruncatedLine(ctx, pod, ts, msg)
+		} else if e, ok := app.envelopeFromLogMessage(ctx, pod, ts, msg); ok {
+			env = &e
+		}
+
+		cursor.LastTimestamp = ts
+		cursor.LastLineHash = lineHash
+
+		// the consumer advances the cursor once the events before it are handled; a burst
+		// of lines without events is queued as one cursor
+		if env == nil && reader.buffered() {
+			pending = true
+			continue
+		}
+		if err := queue(env); err != nil {
+			return cursor, err
+		}
+	}
+}
+
+// resumeFilter skips the lines of a log stream that were read before the cursor it resumes
+// from. SinceTime has second precision, so the stream starts before the cursor. Lines are
+// skipped up to the cursor line, which is found by its hash among the lines sharing its
+// timestamp; a cursor without a hash skips all of them.
+type resumeFilter struct {
+	cursor   store.LogPullerCursor
+	skipping bool
+}
+
+func newResumeFilter(cursor store.LogPullerCursor) *resumeFilter {
+	return &resumeFilter{cursor: cursor, skipping: !cursor.LastTimestamp.IsZero()}
+}
+
+// skip reports whether the line with timestamp ts and hash lineHash was read already.
+// Lines must be passed in stream order.
+func (f *resumeFilter) skip(ts time.Time, lineHash string) bool {
+	if !f.skipping {
+		return false
+	}
+
+	if ts.Before(f.cursor.LastTimestamp) {
+		return true
+	}
+	if ts.Equal(f.cursor.LastTimestamp) {
+		if lineHash == f.cursor.LastLineHash {
+			f.skipping = false
+		}
+		return true
+	}
+
+	f.skipping = false
+	return false
+}
+
+// envelopeFromLogMessage parses the message part of a log line into an Envelope
+// carrying the pod's metadata. It reports false for lines that are not JSON events;
+// JSON lines that fail to decode are stored as dead letters.
+func (app *application) envelopeFromLogMessage(
+	ctx context.Context,
+	pod corev1.Pod,
+	ts time.Time,
+	msg string,
+) (Envelope, bool) {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return Envelope{}, false
+	}
+
+	// JSON format will always starts with `{`
+	if !strings.HasPrefix(msg, "{") {
+		return Envelope{}, false
+	}
+
+	// add kubernetes metadata in envelope
+	env := Envelope{
+		PodName:   pod.Name,
+		NodeName:  pod.Spec.NodeName,
+		Timestamp: ts,
+	}
+
+	if pod.Labels != nil {
+		if c, ok := pod.Labels[componentLabel]; ok {
+			env.Component = c
+		}
+	}
+
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		app.logger.Debugw("failed to unmarshal json",
+			"pod", pod.Name,
+			"payload", msg,
+			"err", err,
+		)
+		app.metrics.parseFailures.WithLabelValues(pod.Name).Inc()
+		app.recordParseFailure(ctx, env, msg, err)
+		return Envelope{}, false
+	}
+
+	app.logger.Infow("parsed event",
+		"env", env)
+
+	return env, true
+}
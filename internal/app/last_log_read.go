@@ -1,25 +1,29 @@
-package main
+package app
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
 )
 
 // shouldSkipByLastLogRead decides whether to skip a client-side event
-// based on the last seen timestamp for that client.
+// based on the last seen timestamp for that client. Re-driven events are never skipped.
 func (app *application) shouldSkipByLastLogRead(
+	ctx context.Context,
 	client store.FLTrainingClient,
 	ts time.Time,
 	event string,
 ) bool {
-	if ts.IsZero() || client.LastLogRead.IsZero() {
+	if isRedrive(ctx) || ts.IsZero() || client.LastLogRead.IsZero() {
 		return false
 	}
 
 	// If the timestamp is not strictly newer than last_log_read, skip it.
 	if !ts.After(client.LastLogRead) {
+		markSkipped(ctx)
+		app.metrics.duplicatesSkipped.WithLabelValues(componentClientApp, event).Inc()
 		app.logger.Debugw("skip client event older than last_log_read",
 			"event", event,
 			"client_id", client.ID,
@@ -35,46 +39,48 @@ func (app *application) shouldSkipByLastLogRead(
 }
 
 // updateClientLastLogRead updates last_log_read for a client if the timestamp is newer.
+// It runs in the transaction of the event, so the marker only advances with the event's data.
 func (app *application) updateClientLastLogRead(
 	ctx context.Context,
 	client store.FLTrainingClient,
 	ts time.Time,
-) {
+) error {
 	if ts.IsZero() {
-		return
+		return nil
 	}
 
 	if !ts.After(client.LastLogRead) {
-		return
+		return nil
 	}
 
-	if err := app.store.FLTrainingClients.UpdateLastLogRead(
+	if err := app.storage(ctx).FLTrainingClients.UpdateLastLogRead(
 		ctx,
 		client.FLTrainingID,
 		client.PartitionID,
 		ts,
 	); err != nil {
-		app.logger.Warnw("failed to update client last_log_read",
-			"error", err,
-			"client_id", client.ID,
-			"fl_training_id", client.FLTrainingID,
-			"partition_id", client.PartitionID,
-		)
+		return fmt.Errorf("update client last_log_read (client_id=%s): %w", client.ID, err)
 	}
+	app.ensured.advanceClientLastLogRead(client.FLTrainingID, client.PartitionID, ts)
+
+	return nil
 }
 
 // shouldSkipByServerLastLogRead decides whether to skip a server-side event
-// based on the last seen timestamp for that training server.
+// based on the last seen timestamp for that training server. Re-driven events are never skipped.
 func (app *application) shouldSkipByServerLastLogRead(
+	ctx context.Context,
 	srv store.FLTrainingServer,
 	ts time.Time,
 	event string,
 ) bool {
-	if ts.IsZero() || srv.LastLogRead.IsZero() {
+	if isRedrive(ctx) || ts.IsZero() || srv.LastLogRead.IsZero() {
 		return false
 	}
 
 	if !ts.After(srv.LastLogRead) {
+		markSkipped(ctx)
+		app.metrics.duplicatesSkipped.WithLabelValues(componentServerApp, event).Inc()
 		app.logger.Debugw("skip server event older than last_log_read",
 			"event", event,
 			"fl_training_id", srv.FLTrainingID,
@@ -88,27 +94,27 @@ func (app *application) shouldSkipByServerLastLogRead(
 }
 
 // updateServerLastLogRead updates last_log_read for a training server if the timestamp is newer.
+// It runs in the transaction of the event, so the marker only advances with the event's data.
 func (app *application) updateServerLastLogRead(
 	ctx context.Context,
 	srv store.FLTrainingServer,
 	ts time.Time,
-) {
+) error {
 	if ts.IsZero() {
-		return
+		return nil
 	}
 
 	if !ts.After(srv.LastLogRead) {
-		return
+		return nil
 	}
 
-	if err := app.store.TrainingServers.UpdateLastLogRead(
+	if err := app.storage(ctx).TrainingServers.UpdateLastLogRead(
 		ctx,
 		srv.FLTrainingID,
 		ts,
 	); err != nil {
-		app.logger.Warnw("failed to update server last_log_read",
-			"error", err,
-			"fl_training_id", srv.FLTrainingID,
-		)
+		return fmt.Errorf("update server last_log_read (fl_training_id=%s): %w", srv.FLTrainingID, err)
 	}
+
+	return nil
 }
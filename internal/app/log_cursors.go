@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/lru"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// cursorFlushInterval is how often the consumer checkpoints, writing the advanced log
+	// cursors to the store.
+	cursorFlushInterval = 5 * time.Second
+
+	// cursorFinalFlushTimeout bounds the last checkpoint once the consumer stopped.
+	cursorFinalFlushTimeout = 10 * time.Second
+
+	// deletedPodsSize is how many deleted pods the cursor tracker remembers, so that the
+	// cursors of their lines still queued do not bring back their rows.
+	deletedPodsSize = 1024
+)
+
+type cursorKey struct {
+	podUID    string
+	container string
+}
+
+// cursorTracker buffers the latest cursor of every followed container in memory
+// and writes the ones that moved to the store in batches, instead of once per line.
+// The cursors of a deleted pod are removed from the store at the next checkpoint.
+type cursorTracker struct {
+	mu    sync.Mutex
+	dirty map[cursorKey]store.LogPullerCursor
+	// deleted holds the UIDs of deleted pods, whose cursors are no longer recorded.
+	deleted *lru.Cache[string, struct{}]
+	// removals are the deleted pods whose persisted cursors are still to be removed.
+	removals []string
+}
+
+func newCursorTracker() *cursorTracker {
+	return &cursorTracker{
+		dirty:   make(map[cursorKey]store.LogPullerCursor),
+		deleted: lru.New[string, struct{}](deletedPodsSize),
+	}
+}
+
+// advance records c as the newest position of its container.
+func (t *cursorTracker) advance(c store.LogPullerCursor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.deleted.Get(c.PodUID); ok {
+		return
+	}
+	t.dirty[cursorKey{podUID: c.PodUID, container: c.Container}] = c
+}
+
+// forget drops the cursors of a deleted pod and schedules removing its persisted ones.
+func (t *cursorTracker) forget(podUID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deleted.Add(podUID, struct{}{})
+	for k := range t.dirty {
+		if k.podUID == podUID {
+			delete(t.dirty, k)
+		}
+	}
+	t.removals = append(t.removals, podUID)
+}
+
+// takeRemovals returns and clears the deleted pods whose persisted cursors are to be removed.
+func (t *cursorTracker) takeRemovals() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	removals := t.removals
+	t.removals = nil
+	return removals
+}
+
+// restoreRemoval puts back a removal that failed.
+func (t *cursorTracker) restoreRemoval(podUID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.removals = append(t.removals, podUID)
+}
+
+// take returns and clears every cursor recorded since the last call, except those of the
+// held containers, which are kept for a later call.
+func (t *cursorTracker) take(held map[cursorKey]bool) []store.LogPullerCursor {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cursors := make([]store.LogPullerCursor, 0, len(t.dirty))
+	for k, c := range t.dirty {
+		if held[k] {
+			continue
+		}
+		cursors = append(cursors, c)
+		delete(t.dirty, k)
+	}
+
+	return cursors
+}
+
+// restore puts back a cursor that failed to persist, unless a newer one was recorded meanwhile.
+func (t *cursorTracker) restore(c store.LogPullerCursor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.deleted.Get(c.PodUID); ok {
+		return
+	}
+
+	key := cursorKey{podUID: c.PodUID, container: c.Container}
+	if _, ok := t.dirty[key]; !ok {
+		t.dirty[key] = c
+	}
+}
+
+// checkpoint persists the log cursors the consumer advanced since the previous checkpoint.
+// The READLINE rows buffered for the events before them are written first, so a persisted
+// cursor never moves past a line that is only held in memory. For the same reason the
+// cursor of a container with an open model weights transfer stays where it is.
+func (app *application) checkpoint(ctx context.Context) {
+	var cursors []store.LogPullerCursor
+	var removals []string
+	if app.cursors != nil {
+		cursors = app.cursors.take(app.weightsTransfers.openSources())
+		removals = app.cursors.takeRemovals()
+	}
+
+	app.flushReadlines(ctx, true)
+
+	for _, c := range cursors {
+		if err := app.store.LogPullerCursors.Upsert(ctx, c); err != nil {
+			app.logger.Warnw("failed to persist log cursor",
+				"pod", c.PodName,
+				"container", c.Container,
+				"error", err,
+			)
+
+			app.cursors.restore(c)
+		}
+	}
+
+	for _, podUID := range removals {
+		if err := app.store.LogPullerCursors.DeleteByPodUID(ctx, podUID); err != nil {
+			app.logger.Warnw("failed to remove log cursors of deleted pod",
+				"pod_uid", podUID,
+				"error", err,
+			)
+
+			app.cursors.restoreRemoval(podUID)
+		}
+	}
+}
+
+type logSourceCtxKey struct{}
+
+// withLogSource attaches the cursor of the log line an event was read from to ctx.
+// Events that were not pulled from a pod have no cursor.
+func withLogSource(ctx context.Context, c *store.LogPullerCursor) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, logSourceCtxKey{}, cursorKey{podUID: c.PodUID, container: c.Container})
+}
+
+// logSource returns the container the event being handled was read from, or nil.
+func logSource(ctx context.Context) *cursorKey {
+	if k, ok := ctx.Value(logSourceCtxKey{}).(cursorKey); ok {
+		return &k
+	}
+	return nil
+}
+
+// loadLogCursor returns the persisted cursor of a pod container, or an empty cursor
+// carrying the pod metadata if none was stored yet.
+func (app *application) loadLogCursor(
+	ctx context.Context,
+	pod corev1.Pod,
+	container string,
+) store.LogPullerCursor {
+	c, err := app.store.LogPullerCursors.Get(ctx, string(pod.UID), container)
+	if err == nil {
+		return c
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		app.logger.Warnw("failed to load log cursor, reading pod from the start",
+			"pod", pod.Name,
+			"container", container,
+			"error", err,
+		)
+	}
+
+	return store.LogPullerCursor{
+		PodUID:    string(pod.UID),
+		Container: container,
+		Namespace: pod.Namespace,
+		PodName:   pod.Name,
+	}
+}
+
+// defaultContainerName returns the container whose logs are pulled, honoring the
+// kubectl default-container annotation like `kubectl logs` does.
+func defaultContainerName(pod corev1.Pod) string {
+	if name, ok := pod.Annotations["kubectl.kubernetes.io/default-container"]; ok && name != "" {
+		return name
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
+
+// hashLogLine identifies a raw log line so lines sharing the cursor timestamp can be told apart.
+func hashLogLine(line string) string {
+	sum := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+func TestResumeFilter(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	type line struct {
+		ts   time.Time
+		text string
+	}
+	// the stream reopened with SinceTime: it starts before the cursor line "b"
+	stream := []line{
+		{at.Add(-time.Second), "old"},
+		{at, "a"},
+		{at, "b"},
+		{at, "c"},
+		{at.Add(time.Second), "d"},
+	}
+
+	tests := []struct {
+		name   string
+		cursor store.LogPullerCursor
+		want   []string
+	}{
+		{name: "no cursor", want: []string{"old", "a", "b", "c", "d"}},
+		{
+			name:   "cursor on a line sharing its timestamp",
+			cursor: store.LogPullerCursor{LastTimestamp: at, LastLineHash: hashLogLine("b")},
+			want:   []string{"c", "d"},
+		},
+		{
+			name:   "cursor on the last line of its timestamp",
+			cursor: store.LogPullerCursor{LastTimestamp: at, LastLineHash: hashLogLine("c")},
+			want:   []string{"d"},
+		},
+		{
+			name:   "cursor without a hash",
+			cursor: store.LogPullerCursor{LastTimestamp: at},
+			want:   []string{"d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newResumeFilter(tt.cursor)
+
+			var got []string
+			for _, l := range stream {
+				if !f.skip(l.ts, hashLogLine(l.text)) {
+					got = append(got, l.text)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("read %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("read %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCursorTrackerForget(t *testing.T) {
+	tracker := newCursorTracker()
+	deleted := store.LogPullerCursor{PodUID: "deleted", Container: "c"}
+	kept := store.LogPullerCursor{PodUID: "kept", Container: "c"}
+
+	tracker.advance(deleted)
+	tracker.advance(kept)
+	tracker.forget("deleted")
+
+	// a line of the deleted pod that was still queued
+	tracker.advance(deleted)
+	tracker.restore(deleted)
+
+	if got := tracker.take(nil); len(got) != 1 || got[0].PodUID != "kept" {
+		t.Fatalf("take() = %v, want only the cursor of the kept pod", got)
+	}
+	if got := tracker.takeRemovals(); len(got) != 1 || got[0] != "deleted" {
+		t.Fatalf("takeRemovals() = %v, want [deleted]", got)
+	}
+	if got := tracker.takeRemovals(); len(got) != 0 {
+		t.Fatalf("takeRemovals() = %v after taking them, want none", got)
+	}
+}
+
+func TestCursorTrackerTakeKeepsHeldCursors(t *testing.T) {
+	tracker := newCursorTracker()
+	held := store.LogPullerCursor{PodUID: "server", Container: "c"}
+	free := store.LogPullerCursor{PodUID: "client", Container: "c"}
+	tracker.advance(held)
+	tracker.advance(free)
+
+	hold := map[cursorKey]bool{{podUID: "server", container: "c"}: true}
+	if got := tracker.take(hold); len(got) != 1 || got[0].PodUID != "client" {
+		t.Fatalf("take() = %v, want only the client cursor", got)
+	}
+	if got := tracker.take(nil); len(got) != 1 || got[0].PodUID != "server" {
+		t.Fatalf("take() after the hold = %v, want the server cursor", got)
+	}
+}
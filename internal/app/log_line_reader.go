@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// logLineReader reads newline-terminated log lines of at most maxSize bytes.
+// Unlike bufio.Scanner it does not give up on a longer line: it returns the line's
+// first maxSize bytes, cut back to a UTF-8 character boundary, marks it truncated and
+// skips the rest of it.
+type logLineReader struct {
+	r       *bufio.Reader
+	maxSize int
+	buf     []byte
+}
+
+func newLogLineReader(r io.Reader, maxSize int) *logLineReader {
+	return &logLineReader{
+		r:       bufio.NewReaderSize(r, min(maxSize, 64*1024)),
+		maxSize: maxSize,
+	}
+}
+
+// next returns the next line without its line ending. It returns io.EOF after the last line.
+func (lr *logLineReader) next() (line string, truncated bool, err error) {
+	lr.buf = lr.buf[:0]
+
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		chunk = bytes.TrimRight(chunk, "\r\n")
+
+		if !truncated {
+			if len(lr.buf)+len(chunk) > lr.maxSize {
+				lr.buf = trimPartialRune(append(lr.buf, chunk[:lr.maxSize-len(lr.buf)]...))
+				truncated = true
+			} else {
+				lr.buf = append(lr.buf, chunk...)
+			}
+		}
+
+		switch {
+		case err == nil:
+			return string(lr.buf), truncated, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			// the line continues in the next chunk
+		case errors.Is(err, io.EOF) && (len(lr.buf) > 0 || truncated):
+			// last line without a newline; the next call returns io.EOF
+			return string(lr.buf), truncated, nil
+		default:
+			return "", false, err
+		}
+	}
+}
+
+// buffered reports whether data read from the source is waiting, i.e. whether more lines
+// of the current burst follow.
+func (lr *logLineReader) buffered() bool {
+	return lr.r.Buffered() > 0
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence at the end of b.
+func trimPartialRune(b []byte) []byte {
+	// a sequence is at most utf8.UTFMax bytes long, so only its start needs finding
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
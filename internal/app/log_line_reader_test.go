@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLogLineReaderNext(t *testing.T) {
+	type line struct {
+		text      string
+		truncated bool
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		maxSize int
+		want    []line
+	}{
+		{
+			name:    "lines within the limit",
+			input:   "a\nbc\n",
+			maxSize: 8,
+			want:    []line{{"a", false}, {"bc", false}},
+		},
+		{
+			name:    "exactly max size",
+			input:   "12345678\nx\n",
+			maxSize: 8,
+			want:    []line{{"12345678", false}, {"x", false}},
+		},
+		{
+			name:    "oversized line is cut and the next line is intact",
+			input:   "123456789\nx\n",
+			maxSize: 8,
+			want:    []line{{"12345678", true}, {"x", false}},
+		},
+		{
+			name:    "line longer than the read buffer",
+			input:   strings.Repeat("a", 100) + "\nx\n",
+			maxSize: 40,
+			want:    []line{{strings.Repeat("a", 40), true}, {"x", false}},
+		},
+		{
+			name:    "final line without newline",
+			input:   "a\nlast",
+			maxSize: 8,
+			want:    []line{{"a", false}, {"last", false}},
+		},
+		{
+			name:    "oversized final line without newline",
+			input:   "123456789",
+			maxSize: 8,
+			want:    []line{{"12345678", true}},
+		},
+		{
+			name:    "CRLF line endings",
+			input:   "a\r\nbc\r\n",
+			maxSize: 8,
+			want:    []line{{"a", false}, {"bc", false}},
+		},
+		{
+			name:    "empty lines",
+			input:   "\n\na\n",
+			maxSize: 8,
+			want:    []line{{"", false}, {"", false}, {"a", false}},
+		},
+		{
+			name:    "cut inside a multi-byte character",
+			input:   "abcdefgé\n", // é is 2 bytes, the limit falls between them
+			maxSize: 8,
+			want:    []line{{"abcdefg", true}},
+		},
+		{
+			name:    "cut after a multi-byte character",
+			input:   "abcdeéx\n",
+			maxSize: 7,
+			want:    []line{{"abcdeé", true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newLogLineReader(strings.NewReader(tt.input), tt.maxSize)
+
+			for i, want := range tt.want {
+				text, truncated, err := r.next()
+				if err != nil {
+					t.Fatalf("line %d: unexpected error %v", i, err)
+				}
+				if text != want.text || truncated != want.truncated {
+					t.Fatalf("line %d = (%q, %v), want (%q, %v)", i, text, truncated, want.text, want.truncated)
+				}
+			}
+
+			if _, _, err := r.next(); !errors.Is(err, io.EOF) {
+				t.Fatalf("after the last line: error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
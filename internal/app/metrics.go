@@ -0,0 +1,154 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "kubelogpull"
+
+// Event results of kubelogpull_events_handled_total.
+const (
+	eventResultOK      = "ok"
+	eventResultError   = "error"
+	eventResultUnknown = "unknown"
+)
+
+// pipelineMetrics are the Prometheus metrics of the ingest pipeline, from reading pod logs
+// to the store queries of the event handlers.
+type pipelineMetrics struct {
+	registry *prometheus.Registry
+	// domain, if set, is gathered on every scrape along with registry; see handler.
+	domain *domainCollector
+
+	linesRead         *prometheus.CounterVec
+	parseFailures     *prometheus.CounterVec
+	truncatedLines    *prometheus.CounterVec
+	eventsHandled     *prometheus.CounterVec
+	eventDuration     *prometheus.HistogramVec
+	duplicatesSkipped *prometheus.CounterVec
+	queryDuration     *prometheus.HistogramVec
+}
+
+func newPipelineMetrics() *pipelineMetrics {
+	m := &pipelineMetrics{
+		registry: prometheus.NewRegistry(),
+		linesRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "log_lines_read_total",
+			Help:      "Log lines read from pod log streams.",
+		}, []string{"pod"}),
+		parseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "log_line_parse_failures_total",
+			Help:      "JSON log lines that could not be decoded into an event.",
+		}, []string{"pod"}),
+		truncatedLines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "log_lines_truncated_total",
+			Help:      "Log lines skipped for exceeding the maximum line size.",
+		}, []string{"pod"}),
+		eventsHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_handled_total",
+			Help:      "Events passed to the event handlers, by result: ok, error or unknown (no handler; component and event are then unknown too).",
+		}, []string{"component", "event", "result"}),
+		eventDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "event_handle_duration_seconds",
+			Help:      "Time to handle an event, including its transaction.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"component", "event"}),
+		duplicatesSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_skipped_duplicate_total",
+			Help:      "Events skipped for not being newer than the last_log_read of their client or server.",
+		}, []string{"component", "event"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "store_query_duration_seconds",
+			Help:      "Latency of store queries by store method and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.linesRead,
+		m.parseFailures,
+		m.truncatedLines,
+		m.eventsHandled,
+		m.eventDuration,
+		m.duplicatesSkipped,
+		m.queryDuration,
+	)
+
+	return m
+}
+
+// watchQueue exports the number of events waiting in the events channel.
+func (m *pipelineMetrics) watchQueue(events chan queuedEvent) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_queue_depth",
+		Help:      "Events and log positions read from pod logs and waiting to be handled.",
+	}, func() float64 { return float64(len(events)) }))
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_queue_capacity",
+		Help:      "Capacity of the events channel.",
+	}, func() float64 { return float64(cap(events)) }))
+}
+
+// forgetPod drops the per-pod series of a pod that went away.
+func (m *pipelineMetrics) forgetPod(pod string) {
+	m.linesRead.DeleteLabelValues(pod)
+	m.parseFailures.DeleteLabelValues(pod)
+	m.truncatedLines.DeleteLabelValues(pod)
+}
+
+func (m *pipelineMetrics) observeEvent(component, event string, d time.Duration, err error) {
+	result := eventResultOK
+	if err != nil {
+		result = eventResultError
+	}
+	m.eventsHandled.WithLabelValues(component, event, result).Inc()
+	m.eventDuration.WithLabelValues(component, event).Observe(d.Seconds())
+}
+
+// observeUnknownEvent counts an event no handler is registered for. Its component and event come
+// straight from the log line, so they are not used as labels: every unknown event shares one series.
+func (m *pipelineMetrics) observeUnknownEvent() {
+	m.eventsHandled.WithLabelValues(eventResultUnknown, eventResultUnknown, eventResultUnknown).Inc()
+}
+
+// ObserveQuery implements store.QueryObserver.
+func (m *pipelineMetrics) ObserveQuery(method string, d time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.queryDuration.WithLabelValues(method, result).Observe(d.Seconds())
+}
+
+// handler serves the metrics. The domain gauges are gathered from a registry made for each
+// scrape, so that their store queries run under the context of the scrape request.
+func (m *pipelineMetrics) handler() http.Handler {
+	opts := promhttp.HandlerOpts{Registry: m.registry}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatherers := prometheus.Gatherers{m.registry}
+		if m.domain != nil {
+			scrape := prometheus.NewRegistry()
+			scrape.MustRegister(m.domain.withContext(r.Context()))
+			gatherers = append(gatherers, scrape)
+		}
+
+		promhttp.HandlerFor(gatherers, opts).ServeHTTP(w, r)
+	})
+}
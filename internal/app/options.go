@@ -0,0 +1,27 @@
+package app
+
+import "github.com/KanathipP/KubeLogPullStoreGopher/internal/events"
+
+// Option customizes the service started by RunServer or RunIngest.
+type Option func(*options)
+
+type options struct {
+	eventRegistrations []func(*events.Registry) error
+}
+
+// WithEventRegistrations lets register add events, usually with events.Register, after the
+// built-in ones are registered. The service does not start if register returns an error,
+// e.g. because it registers an event that already has a handler.
+func WithEventRegistrations(register func(*events.Registry) error) Option {
+	return func(o *options) {
+		o.eventRegistrations = append(o.eventRegistrations, register)
+	}
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podFollowers tracks the running log follower of every pod, keyed by pod UID
+// so that a recreated pod with the same name gets a fresh follower.
+type podFollowers struct {
+	mu      sync.Mutex
+	cancels map[types.UID]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newPodFollowers() *podFollowers {
+	return &podFollowers{
+		cancels: make(map[types.UID]context.CancelFunc),
+	}
+}
+
+// start runs follow for pod in its own goroutine unless one is already running.
+// It reports whether a new follower was started.
+func (f *podFollowers) start(
+	ctx context.Context,
+	pod *corev1.Pod,
+	follow func(ctx context.Context, pod corev1.Pod),
+) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cancels[pod.UID]; ok {
+		return false
+	}
+
+	followCtx, cancel := context.WithCancel(ctx)
+	f.cancels[pod.UID] = cancel
+
+	f.wg.Add(1)
+	go func(pod corev1.Pod) {
+		defer f.wg.Done()
+		follow(followCtx, pod)
+	}(*pod)
+
+	return true
+}
+
+// stop cancels the follower of the pod with the given UID, if any.
+// It reports whether a follower was running.
+func (f *podFollowers) stop(uid types.UID) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cancel, ok := f.cancels[uid]
+	if !ok {
+		return false
+	}
+
+	cancel()
+	delete(f.cancels, uid)
+
+	return true
+}
+
+// stopAll cancels every follower and waits for them to return.
+func (f *podFollowers) stopAll() {
+	f.mu.Lock()
+	for uid, cancel := range f.cancels {
+		cancel()
+		delete(f.cancels, uid)
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+}
+
+// streamLimiter bounds how many pod log streams are being opened at the same time,
+// so a burst of new pods does not flood the API server with log requests.
+// A limiter without slots does not limit anything.
+type streamLimiter struct {
+	slots chan struct{}
+}
+
+func newStreamLimiter(concurrency int) *streamLimiter {
+	if concurrency <= 0 {
+		return &streamLimiter{}
+	}
+	return &streamLimiter{slots: make(chan struct{}, concurrency)}
+}
+
+// tryAcquire takes a slot if one is free right now.
+func (l *streamLimiter) tryAcquire() bool {
+	if l.slots == nil {
+		return true
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire waits for a free slot until ctx is canceled.
+func (l *streamLimiter) acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by tryAcquire or acquire.
+func (l *streamLimiter) release() {
+	if l.slots == nil {
+		return
+	}
+	<-l.slots
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/google/uuid"
+)
+
+// readlineBatch holds the buffered READLINE rows of one client.
+type readlineBatch struct {
+	client   store.FLTrainingClient
+	logs     []store.ClientLog
+	envs     []Envelope // kept to dead-letter the rows if the flush fails
+	lastTS   time.Time
+	openedAt time.Time
+}
+
+// readlineBatcher buffers READLINE rows per client so they are written with one
+// multi-row INSERT and a single last_log_read update per flush. A batch is flushed
+// once it holds size rows or once it is older than maxAge.
+type readlineBatcher struct {
+	mu      sync.Mutex
+	batches map[uuid.UUID]*readlineBatch
+	size    int
+	maxAge  time.Duration
+}
+
+func newReadlineBatcher(size int, maxAge string) (*readlineBatcher, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("readline batch size must be positive, got %d", size)
+	}
+
+	duration, err := time.ParseDuration(maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readlineBatcher{
+		batches: make(map[uuid.UUID]*readlineBatch),
+		size:    size,
+		maxAge:  duration,
+	}, nil
+}
+
+// add buffers a log row of client.
+func (b *readlineBatcher) add(client store.FLTrainingClient, log store.ClientLog, env Envelope) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch, ok := b.batches[client.ID]
+	if !ok {
+		batch = &readlineBatch{client: client, openedAt: time.Now()}
+		b.batches[client.ID] = batch
+	}
+
+	batch.logs = append(batch.logs, log)
+	batch.envs = append(batch.envs, env)
+	if log.ClientOutputAt.After(batch.lastTS) {
+		batch.lastTS = log.ClientOutputAt
+	}
+}
+
+// take removes and returns the batches that are full, older than maxAge, or all of them.
+func (b *readlineBatcher) take(now time.Time, all bool) []*readlineBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var ready []*readlineBatch
+	for id, batch := range b.batches {
+		if all || len(batch.logs) >= b.size || now.Sub(batch.openedAt) >= b.maxAge {
+			ready = append(ready, batch)
+			delete(b.batches, id)
+		}
+	}
+
+	return ready
+}
+
+// flushReadlines writes the batches that are due, or every batch if all is set.
+func (app *application) flushReadlines(ctx context.Context, all bool) {
+	for _, batch := range app.readlines.take(time.Now(), all) {
+		app.flushReadlineBatch(ctx, batch)
+	}
+}
+
+// flushReadlineBatch writes one client's rows and advances its last_log_read in one transaction.
+// If that fails, every buffered event is stored as a dead letter so it can be re-driven.
+func (app *application) flushReadlineBatch(ctx context.Context, batch *readlineBatch) {
+	client := batch.client
+
+	err := app.store.WithTx(ctx, func(tx *store.Storage) error {
+		if err := tx.ClientLogs.CreateBatch(ctx, batch.logs); err != nil {
+			return fmt.Errorf("insert client logs: %w", err)
+		}
+
+		return tx.FLTrainingClients.UpdateLastLogRead(
+			ctx,
+			client.FLTrainingID,
+			client.PartitionID,
+			batch.lastTS,
+		)
+	})
+	if err != nil {
+		app.logger.Errorw("failed to flush READLINE batch",
+			"client_id", client.ID,
+			"fl_training_id", client.FLTrainingID,
+			"partition_id", client.PartitionID,
+			"rows", len(batch.logs),
+			"error", err,
+		)
+		for _, env := range batch.envs {
+			app.recordHandleFailure(ctx, env, err)
+		}
+		return
+	}
+	app.ensured.advanceClientLastLogRead(client.FLTrainingID, client.PartitionID, batch.lastTS)
+
+	app.logger.Debugw("flushed READLINE batch",
+		"client_id", client.ID,
+		"rows", len(batch.logs),
+		"last_log_read", batch.lastTS,
+	)
+}
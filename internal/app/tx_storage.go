@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+type txStorageCtxKey struct{}
+
+// withTxStorage attaches the transaction-bound storage of the current event to ctx.
+func withTxStorage(ctx context.Context, s *store.Storage) context.Context {
+	return context.WithValue(ctx, txStorageCtxKey{}, s)
+}
+
+// storage returns the storage event handlers must write through: the transaction of
+// the event being handled if there is one, the connection pool otherwise.
+func (app *application) storage(ctx context.Context) *store.Storage {
+	if s, ok := ctx.Value(txStorageCtxKey{}).(*store.Storage); ok {
+		return s
+	}
+	return app.store
+}
+
+type txCommitHooksCtxKey struct{}
+
+// withCommitHooks attaches a list to ctx that onCommit appends to. The caller runs the
+// hooks once the transaction of the event committed and drops them otherwise.
+func withCommitHooks(ctx context.Context, hooks *[]func()) context.Context {
+	return context.WithValue(ctx, txCommitHooksCtxKey{}, hooks)
+}
+
+// onCommit defers fn until the transaction of the event being handled committed, so state
+// kept outside the database never holds writes that were rolled back. Without a
+// transaction fn runs right away.
+func onCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(txCommitHooksCtxKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errUnknownWeightsTransfer = errors.New("unknown model weights transfer")
+
+type weightsTransferKey struct {
+	flTrainingID string
+	serverRound  int
+	transferID   string
+}
+
+// weightsTransfer collects the chunks of one MODEL_WEIGHTS_BEGIN/CHUNK/END transfer.
+// source is the container the BEGIN line was read from, or nil if it was not pulled from a pod.
+// replayed marks a transfer whose BEGIN was read before, so its chunks are not new either.
+type weightsTransfer struct {
+	totalChunks int
+	chunks      map[int][]byte
+	size        int64
+	lastSeen    time.Time
+	source      *cursorKey
+	replayed    bool
+}
+
+// weightsTransfers reassembles chunked model weights in memory. A transfer that sees no
+// chunk for timeout is dropped, and one that grows past maxSize bytes is rejected. All
+// transfers together may hold at most maxTotalSize bytes; the transfer whose chunk would
+// exceed that is dropped.
+type weightsTransfers struct {
+	mu           sync.Mutex
+	transfers    map[weightsTransferKey]*weightsTransfer
+	timeout      time.Duration
+	maxSize      int64
+	maxTotalSize int64
+	totalSize    int64
+}
+
+func newWeightsTransfers(timeout string, maxSize, maxTotalSize int64) (*weightsTransfers, error) {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize < 1 {
+		return nil, fmt.Errorf("model weights transfer max size must be positive, got %d", maxSize)
+	}
+	if maxTotalSize < maxSize {
+		return nil, fmt.Errorf("model weights transfers max total size %d is below the max size %d", maxTotalSize, maxSize)
+	}
+
+	return &weightsTransfers{
+		transfers:    make(map[weightsTransferKey]*weightsTransfer),
+		timeout:      duration,
+		maxSize:      maxSize,
+		maxTotalSize: maxTotalSize,
+	}, nil
+}
+
+// begin starts a transfer, discarding any earlier transfer with the same key.
+// Every chunk carries at least one byte, so a transfer of more than maxSize chunks is rejected.
+func (t *weightsTransfers) begin(key weightsTransferKey, totalChunks int, source *cursorKey, replayed bool, now time.Time) error {
+	if int64(totalChunks) > t.maxSize {
+		return fmt.Errorf("transfer of %d chunks exceeds %d bytes", totalChunks, t.maxSize)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(key)
+	t.transfers[key] = &weightsTransfer{
+		totalChunks: totalChunks,
+		chunks:      make(map[int][]byte),
+		lastSeen:    now,
+		source:      source,
+		replayed:    replayed,
+	}
+
+	return nil
+}
+
+// addChunk stores chunk seq of a transfer and reports whether the chunk is new. A chunk that
+// was already received is ignored, so re-read log lines do no harm; the chunks of a replayed
+// transfer are stored but not new.
+func (t *weightsTransfers) addChunk(key weightsTransferKey, seq int, data []byte, now time.Time) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tr, ok := t.transfers[key]
+	if !ok {
+		return false, errUnknownWeightsTransfer
+	}
+
+	if seq < 0 || seq >= tr.totalChunks {
+		return false, fmt.Errorf("chunk %d out of range, transfer has %d chunks", seq, tr.totalChunks)
+	}
+	if len(data) == 0 {
+		return false, fmt.Errorf("chunk %d is empty", seq)
+	}
+	if _, ok := tr.chunks[seq]; ok {
+		return false, nil
+	}
+
+	size := int64(len(data))
+	if tr.size+size > t.maxSize {
+		t.remove(key)
+		return false, fmt.Errorf("transfer exceeds %d bytes", t.maxSize)
+	}
+	if t.totalSize+size > t.maxTotalSize {
+		t.remove(key)
+		return false, fmt.Errorf("transfers in progress exceed %d bytes", t.maxTotalSize)
+	}
+
+	tr.chunks[seq] = data
+	tr.size += size
+	t.totalSize += size
+	tr.lastSeen = now
+
+	return !tr.replayed, nil
+}
+
+// assemble returns the chunks of a transfer joined in sequence order. It fails if a chunk
+// is missing. The transfer is kept until drop, so an END whose weights could not be stored
+// can be re-driven while the transfer has not timed out.
+func (t *weightsTransfers) assemble(key weightsTransferKey) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tr, ok := t.transfers[key]
+	if !ok {
+		return nil, errUnknownWeightsTransfer
+	}
+
+	if len(tr.chunks) != tr.totalChunks {
+		return nil, fmt.Errorf("transfer incomplete: received %d of %d chunks", len(tr.chunks), tr.totalChunks)
+	}
+
+	data := make([]byte, 0, tr.size)
+	for seq := range tr.totalChunks {
+		data = append(data, tr.chunks[seq]...)
+	}
+
+	return data, nil
+}
+
+// drop removes a transfer, if there is one.
+func (t *weightsTransfers) drop(key weightsTransferKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(key)
+}
+
+// openSources returns the containers that the open transfers were read from. Their log
+// cursors are not persisted until the transfers end, so that a restart reads every open
+// transfer again from its BEGIN line.
+func (t *weightsTransfers) openSources() map[cursorKey]bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sources := make(map[cursorKey]bool)
+	for _, tr := range t.transfers {
+		if tr.source != nil {
+			sources[*tr.source] = true
+		}
+	}
+
+	return sources
+}
+
+// remove drops a transfer and returns it, or nil if there is none. t.mu must be held.
+func (t *weightsTransfers) remove(key weightsTransferKey) *weightsTransfer {
+	tr, ok := t.transfers[key]
+	if !ok {
+		return nil
+	}
+
+	delete(t.transfers, key)
+	t.totalSize -= tr.size
+
+	return tr
+}
+
+// expire drops the transfers that saw no chunk for the timeout and returns their keys.
+func (t *weightsTransfers) expire(now time.Time) []weightsTransferKey {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var expired []weightsTransferKey
+	for key, tr := range t.transfers {
+		if now.Sub(tr.lastSeen) >= t.timeout {
+			expired = append(expired, key)
+			t.remove(key)
+		}
+	}
+
+	return expired
+}
+
+// runWeightsTransferJanitor drops timed out transfers until ctx is canceled.
+func (app *application) runWeightsTransferJanitor(ctx context.Context) {
+	ticker := time.NewTicker(max(app.weightsTransfers.timeout/4, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, key := range app.weightsTransfers.expire(time.Now()) {
+				app.logger.Warnw("model weights transfer timed out, discarding it",
+					"fl_training_id", key.flTrainingID,
+					"server_round", key.serverRound,
+					"transfer_id", key.transferID,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWeightsTransfers(t *testing.T) {
+	key := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "x"}
+	now := time.Now()
+
+	type chunk struct {
+		seq  int
+		data string
+	}
+
+	tests := []struct {
+		name         string
+		maxSize      int64
+		totalChunks  int
+		chunks       []chunk
+		wantBeginErr bool
+		wantChunkErr bool // of the last chunk
+		want         string
+		wantErr      bool // of assemble
+	}{
+		{
+			name:        "in order",
+			maxSize:     100,
+			totalChunks: 3,
+			chunks:      []chunk{{0, "ab"}, {1, "cd"}, {2, "e"}},
+			want:        "abcde",
+		},
+		{
+			name:        "out of order",
+			maxSize:     100,
+			totalChunks: 3,
+			chunks:      []chunk{{2, "e"}, {0, "ab"}, {1, "cd"}},
+			want:        "abcde",
+		},
+		{
+			name:        "duplicate chunk is ignored",
+			maxSize:     100,
+			totalChunks: 2,
+			chunks:      []chunk{{0, "ab"}, {0, "zz"}, {1, "cd"}},
+			want:        "abcd",
+		},
+		{
+			name:        "missing chunk",
+			maxSize:     100,
+			totalChunks: 3,
+			chunks:      []chunk{{0, "ab"}, {2, "e"}},
+			wantErr:     true,
+		},
+		{
+			name:         "seq out of range",
+			maxSize:      100,
+			totalChunks:  2,
+			chunks:       []chunk{{2, "ab"}},
+			wantChunkErr: true,
+			wantErr:      true,
+		},
+		{
+			name:         "negative seq",
+			maxSize:      100,
+			totalChunks:  2,
+			chunks:       []chunk{{-1, "ab"}},
+			wantChunkErr: true,
+			wantErr:      true,
+		},
+		{
+			name:         "empty chunk",
+			maxSize:      100,
+			totalChunks:  1,
+			chunks:       []chunk{{0, ""}},
+			wantChunkErr: true,
+			wantErr:      true,
+		},
+		{
+			name:         "size cap drops the transfer",
+			maxSize:      4,
+			totalChunks:  3,
+			chunks:       []chunk{{0, "ab"}, {1, "cd"}, {2, "e"}},
+			wantChunkErr: true,
+			wantErr:      true,
+		},
+		{
+			name:         "more chunks than bytes allowed",
+			maxSize:      4,
+			totalChunks:  5,
+			wantBeginErr: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wt, err := newWeightsTransfers("1m", tt.maxSize, tt.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = wt.begin(key, tt.totalChunks, nil, false, now)
+			if (err != nil) != tt.wantBeginErr {
+				t.Fatalf("begin() error = %v, wantErr %v", err, tt.wantBeginErr)
+			}
+
+			for i, c := range tt.chunks {
+				_, err := wt.addChunk(key, c.seq, []byte(c.data), now)
+				last := i == len(tt.chunks)-1
+				if err != nil && !(last && tt.wantChunkErr) {
+					t.Fatalf("addChunk(%d) error = %v", c.seq, err)
+				}
+				if err == nil && last && tt.wantChunkErr {
+					t.Fatalf("addChunk(%d) succeeded, want error", c.seq)
+				}
+			}
+
+			got, err := wt.assemble(key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assemble() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("assemble() = %q, want %q", got, tt.want)
+			}
+
+			wt.drop(key)
+			if wt.totalSize != 0 {
+				t.Fatalf("totalSize = %d after drop, want 0", wt.totalSize)
+			}
+		})
+	}
+}
+
+func TestWeightsTransfersTotalSize(t *testing.T) {
+	wt, err := newWeightsTransfers("1m", 4, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	a := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "a"}
+	b := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "b"}
+
+	for _, key := range []weightsTransferKey{a, b} {
+		if err := wt.begin(key, 2, nil, false, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := wt.addChunk(a, 0, []byte("aaaa"), now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.addChunk(b, 0, []byte("bbb"), now); err == nil {
+		t.Fatal("addChunk past the total size succeeded, want error")
+	}
+	if _, err := wt.assemble(b); !errors.Is(err, errUnknownWeightsTransfer) {
+		t.Fatalf("assemble() of the dropped transfer error = %v, want errUnknownWeightsTransfer", err)
+	}
+
+	// the memory of a dropped transfer is available again
+	wt.drop(a)
+	if err := wt.begin(b, 2, nil, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.addChunk(b, 0, []byte("bbb"), now); err != nil {
+		t.Fatalf("addChunk() after the space was freed: %v", err)
+	}
+}
+
+func TestWeightsTransfersUnknown(t *testing.T) {
+	wt, err := newWeightsTransfers("1m", 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "x"}
+
+	if _, err := wt.addChunk(key, 0, []byte("a"), time.Now()); !errors.Is(err, errUnknownWeightsTransfer) {
+		t.Fatalf("addChunk() error = %v, want errUnknownWeightsTransfer", err)
+	}
+	if _, err := wt.assemble(key); !errors.Is(err, errUnknownWeightsTransfer) {
+		t.Fatalf("assemble() error = %v, want errUnknownWeightsTransfer", err)
+	}
+}
+
+func TestWeightsTransfersKeptUntilDropped(t *testing.T) {
+	wt, err := newWeightsTransfers("1m", 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	key := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "x"}
+	source := cursorKey{podUID: "uid", container: "server"}
+
+	if err := wt.begin(key, 1, &source, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.addChunk(key, 0, []byte("ab"), now); err != nil {
+		t.Fatal(err)
+	}
+
+	// an END that failed to store the weights can be handled again
+	for range 2 {
+		got, err := wt.assemble(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "ab" {
+			t.Fatalf("assemble() = %q, want %q", got, "ab")
+		}
+	}
+	if sources := wt.openSources(); !sources[source] || len(sources) != 1 {
+		t.Fatalf("openSources() = %v, want only %v", sources, source)
+	}
+
+	wt.drop(key)
+	if sources := wt.openSources(); len(sources) != 0 {
+		t.Fatalf("openSources() = %v after drop, want none", sources)
+	}
+}
+
+func TestWeightsTransfersFreshChunks(t *testing.T) {
+	wt, err := newWeightsTransfers("1m", 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	live := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "live"}
+	replayed := weightsTransferKey{flTrainingID: "t", serverRound: 1, transferID: "replayed"}
+
+	if err := wt.begin(live, 2, nil, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.begin(replayed, 2, nil, true, now); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  weightsTransferKey
+		seq  int
+		want bool
+	}{
+		{live, 0, true},
+		{live, 0, false}, // re-read chunk
+		{live, 1, true},
+		{replayed, 0, false},
+	}
+	for _, tt := range tests {
+		fresh, err := wt.addChunk(tt.key, tt.seq, []byte("a"), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fresh != tt.want {
+			t.Errorf("addChunk(%s, %d) fresh = %v, want %v", tt.key.transferID, tt.seq, fresh, tt.want)
+		}
+	}
+}
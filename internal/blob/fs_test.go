@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSStore(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	s, err := NewFSStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "abcdef0123"
+	data := []byte("model weights")
+
+	if ok, err := s.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists() before Put = %v, %v; want false, nil", ok, err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() before Put error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	// writing the same key again is a no-op
+	if err := s.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := s.Exists(ctx, key); err != nil || !ok {
+		t.Fatalf("Exists() after Put = %v, %v; want true, nil", ok, err)
+	}
+
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Get() = %q, want %q", got, data)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "ab", key)); err != nil {
+		t.Fatalf("blob not stored below its key prefix: %v", err)
+	}
+}
+
+func TestFSStorePutSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "abcdef0123"
+	if err := s.Put(ctx, key, bytes.NewReader([]byte("short")), 10); err == nil {
+		t.Fatal("Put() with a wrong size succeeded, want error")
+	}
+
+	// the partial blob must not be visible
+	if ok, err := s.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists() after a failed Put = %v, %v; want false, nil", ok, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.root, "ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("failed Put left %d files behind", len(entries))
+	}
+}
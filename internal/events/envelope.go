@@ -0,0 +1,17 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Envelope represents a single structured log event coming from a pod log line.
+// The raw message from Kubernetes is a JSON object that is unmarshaled into this struct.
+type Envelope struct {
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	PodName   string          `json:"-"`
+	NodeName  string          `json:"-"`
+	Component string          `json:"-"` // e.g. "clientapp" or "serverapp"
+	Timestamp time.Time       `json:"-"` // timestamp parsed from Kubernetes log line
+}
@@ -0,0 +1,199 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownEvent   = errors.New("unknown event")
+	ErrDuplicateEvent = errors.New("event already registered")
+)
+
+// Key identifies an event type by the component label of the emitting pod and the event name.
+type Key struct {
+	Component string
+	Event     string
+}
+
+// Spec describes how one event type is decoded, validated and handled.
+// Decode and Validate are optional; the payload is decoded with encoding/json by default.
+type Spec[P any] struct {
+	Component   string
+	Event       string
+	Description string
+	Decode      func(json.RawMessage) (P, error)
+	Validate    func(P) error
+	Handle      func(ctx context.Context, env Envelope, p P) error
+}
+
+// Info describes a registered event for introspection.
+type Info struct {
+	Component     string         `json:"component"`
+	Event         string         `json:"event"`
+	Description   string         `json:"description"`
+	PayloadType   string         `json:"payload_type"`
+	PayloadFields []PayloadField `json:"payload_fields"`
+}
+
+// PayloadField is a JSON field of a registered payload type.
+type PayloadField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type entry struct {
+	info   Info
+	handle func(ctx context.Context, env Envelope) error
+}
+
+// Registry maps (component, event) to the handler of that event.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[Key]entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[Key]entry)}
+}
+
+// Register adds the event described by spec to r.
+func Register[P any](r *Registry, spec Spec[P]) error {
+	if spec.Component == "" || spec.Event == "" {
+		return errors.New("register event: component and event are required")
+	}
+	if spec.Handle == nil {
+		return fmt.Errorf("register %s/%s: handler is required", spec.Component, spec.Event)
+	}
+
+	decode := spec.Decode
+	if decode == nil {
+		decode = func(raw json.RawMessage) (P, error) {
+			var p P
+			err := json.Unmarshal(raw, &p)
+			return p, err
+		}
+	}
+
+	handle := func(ctx context.Context, env Envelope) error {
+		p, err := decode(env.Payload)
+		if err != nil {
+			return fmt.Errorf("%s unmarshal: %w", spec.Event, err)
+		}
+
+		if spec.Validate != nil {
+			if err := spec.Validate(p); err != nil {
+				return fmt.Errorf("%s validate: %w", spec.Event, err)
+			}
+		}
+
+		return spec.Handle(ctx, env, p)
+	}
+
+	payloadType := reflect.TypeFor[P]()
+
+	key := Key{Component: spec.Component, Event: spec.Event}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[key]; ok {
+		return fmt.Errorf("register %s/%s: %w", spec.Component, spec.Event, ErrDuplicateEvent)
+	}
+
+	r.entries[key] = entry{
+		info: Info{
+			Component:     spec.Component,
+			Event:         spec.Event,
+			Description:   spec.Description,
+			PayloadType:   payloadType.String(),
+			PayloadFields: payloadFields(payloadType),
+		},
+		handle: handle,
+	}
+
+	return nil
+}
+
+// Lookup reports whether an event is registered for (component, event).
+func (r *Registry) Lookup(component, event string) (Info, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[Key{Component: component, Event: event}]
+	return e.info, ok
+}
+
+// Dispatch decodes, validates and handles env with the handler registered for it.
+// It returns an error wrapping ErrUnknownEvent if no handler is registered.
+func (r *Registry) Dispatch(ctx context.Context, env Envelope) error {
+	r.mu.RLock()
+	e, ok := r.entries[Key{Component: env.Component, Event: env.Event}]
+	r.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%s/%s: %w", env.Component, env.Event, ErrUnknownEvent)
+	}
+
+	return e.handle(ctx, env)
+}
+
+// List returns every registered event, sorted by component and event.
+func (r *Registry) List() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]Info, 0, len(r.entries))
+	for _, e := range r.entries {
+		infos = append(infos, e.info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Component != infos[j].Component {
+			return infos[i].Component < infos[j].Component
+		}
+		return infos[i].Event < infos[j].Event
+	})
+
+	return infos
+}
+
+// payloadFields lists the JSON fields of a struct payload type.
+func payloadFields(t reflect.Type) []PayloadField {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []PayloadField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fields = append(fields, PayloadField{Name: name, Type: f.Type.String()})
+	}
+
+	return fields
+}
@@ -0,0 +1,184 @@
+// Package export writes the graphs of a training as files for offline analysis.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat validates the name of an export format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatCSV, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, expected csv or parquet", s)
+	}
+}
+
+// ContentType returns the MIME type of files in format f.
+func (f Format) ContentType() string {
+	if f == FormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindFloat
+	kindTime
+)
+
+type column struct {
+	name string
+	kind columnKind
+	// optional columns may hold nil, written as an empty CSV field or a Parquet null
+	optional bool
+}
+
+// table holds rows whose values are a string, int, float64 or time.Time, matching the column
+// kinds, or nil in optional columns.
+type table struct {
+	columns []column
+	rows    [][]any
+}
+
+var trainingColumns = []column{
+	{"fl_training_id", kindString, false},
+	{"partition_id", kindInt, false},
+	{"server_round", kindInt, false},
+	{"optimizer", kindString, false},
+	{"learning_rate", kindFloat, false},
+	{"num_epochs", kindInt, false},
+	{"batch_size", kindInt, false},
+	{"epoch", kindInt, false},
+	{"trained_batch", kindInt, false},
+	{"train_loss", kindFloat, true},
+	{"val_loss", kindFloat, true},
+	{"accuracy", kindFloat, true},
+	{"epoch_elapsed_time", kindFloat, true},
+	{"event_at", kindTime, false},
+}
+
+var testingColumns = []column{
+	{"fl_training_id", kindString, false},
+	{"partition_id", kindInt, false},
+	{"server_round", kindInt, false},
+	{"criterion", kindString, false},
+	{"batch_size", kindInt, false},
+	{"test_loss", kindFloat, true},
+	{"accuracy", kindFloat, true},
+	{"event_at", kindTime, false},
+}
+
+// WriteTrainingPoints writes the epoch points of a training, one row per point, to w in format f.
+func WriteTrainingPoints(w io.Writer, f Format, rows []store.TrainingGraphExportRow) error {
+	t := table{columns: trainingColumns, rows: make([][]any, 0, len(rows))}
+	for _, r := range rows {
+		t.rows = append(t.rows, []any{
+			r.FLTrainingID,
+			r.PartitionID,
+			r.ServerRound,
+			r.Optimizer,
+			r.LearningRate,
+			r.NumEpochs,
+			r.BatchSize,
+			r.CurrentEpoch,
+			r.TrainedBatch,
+			optionalFloat(r.TrainLoss),
+			optionalFloat(r.ValLoss),
+			optionalFloat(r.Accuracy),
+			optionalFloat(r.EpochElapsedTime),
+			r.EventAt,
+		})
+	}
+
+	return t.write(w, f)
+}
+
+// WriteTestingPoints writes the testing points of a training, one row per point, to w in format f.
+func WriteTestingPoints(w io.Writer, f Format, rows []store.TestingGraphExportRow) error {
+	t := table{columns: testingColumns, rows: make([][]any, 0, len(rows))}
+	for _, r := range rows {
+		t.rows = append(t.rows, []any{
+			r.FLTrainingID,
+			r.PartitionID,
+			r.ServerRound,
+			r.Criterion,
+			r.BatchSize,
+			optionalFloat(r.TestLoss),
+			optionalFloat(r.Accuracy),
+			r.EventAt,
+		})
+	}
+
+	return t.write(w, f)
+}
+
+// optionalFloat turns a nil *float64 into a nil value of an optional column.
+func optionalFloat(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func (t table) write(w io.Writer, f Format) error {
+	if f == FormatParquet {
+		return writeParquet(w, t)
+	}
+	return writeCSV(w, t)
+}
+
+func writeCSV(w io.Writer, t table) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(t.columns))
+	for _, row := range t.rows {
+		for i, v := range row {
+			switch v := v.(type) {
+			case nil:
+				record[i] = ""
+			case string:
+				record[i] = v
+			case int:
+				record[i] = strconv.Itoa(v)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			case time.Time:
+				record[i] = v.UTC().Format(time.RFC3339Nano)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+)
+
+func float(v float64) *float64 { return &v }
+
+var testingRows = []store.TestingGraphExportRow{
+	{
+		FLTrainingID: "fl-1", PartitionID: 0, ServerRound: 1, Criterion: "ce", BatchSize: 32,
+		TestLoss: float(0.75), Accuracy: float(0.5), EventAt: time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC),
+	},
+	{
+		FLTrainingID: "fl-1", PartitionID: 0, ServerRound: 2, Criterion: "ce", BatchSize: 32,
+		TestLoss: nil, Accuracy: float(0.625), EventAt: time.Date(2025, 1, 2, 3, 5, 0, 0, time.UTC),
+	},
+	{
+		FLTrainingID: "fl-1", PartitionID: 1, ServerRound: 1, Criterion: "", BatchSize: 0,
+		TestLoss: nil, Accuracy: nil, EventAt: time.Date(2025, 1, 2, 3, 6, 0, 0, time.UTC),
+	},
+}
+
+func TestWriteTestingPointsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTestingPoints(&buf, FormatCSV, testingRows); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"fl_training_id", "partition_id", "server_round", "criterion", "batch_size", "test_loss", "accuracy", "event_at"},
+		{"fl-1", "0", "1", "ce", "32", "0.75", "0.5", "2025-01-02T03:04:05.123456Z"},
+		{"fl-1", "0", "2", "ce", "32", "", "0.625", "2025-01-02T03:05:00Z"},
+		{"fl-1", "1", "1", "", "0", "", "", "2025-01-02T03:06:00Z"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("CSV = %q, want %q", records, want)
+	}
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize is the number of rows per row group.
+const parquetRowGroupSize = 65536
+
+// writeParquet writes t as a Parquet file. Strings are UTF-8 byte arrays, ints INT32,
+// floats DOUBLE and times microsecond timestamps in UTC; optional columns are nullable.
+func writeParquet(w io.Writer, t table) error {
+	recordType := parquetRecordType(t.columns)
+
+	pw := parquet.NewWriter(w,
+		parquet.SchemaOf(reflect.New(recordType).Interface()),
+		parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		parquet.CreatedBy("KubeLogPullStoreGopher export", "", ""),
+	)
+
+	record := reflect.New(recordType)
+	for _, row := range t.rows {
+		record.Elem().SetZero()
+		for i, v := range row {
+			if v == nil {
+				continue
+			}
+
+			field := record.Elem().Field(i)
+			if t.columns[i].optional {
+				field.Set(reflect.New(field.Type().Elem()))
+				field = field.Elem()
+			}
+			setParquetValue(field, v)
+		}
+
+		if err := pw.Write(record.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return pw.Close()
+}
+
+// parquetRecordType returns a struct type with one field per column, in column order, so
+// that the columns of the file keep the order of the table.
+func parquetRecordType(columns []column) reflect.Type {
+	fields := make([]reflect.StructField, len(columns))
+	for i, c := range columns {
+		var typ reflect.Type
+		tag := c.name
+
+		switch c.kind {
+		case kindString:
+			typ = reflect.TypeFor[string]()
+		case kindInt:
+			typ = reflect.TypeFor[int32]()
+		case kindFloat:
+			typ = reflect.TypeFor[float64]()
+		case kindTime:
+			typ = reflect.TypeFor[time.Time]()
+			tag += ",timestamp(microsecond)"
+		}
+
+		if c.optional {
+			typ = reflect.PointerTo(typ)
+			tag += ",optional"
+		}
+
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Column%d", i),
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf("parquet:%q", tag)),
+		}
+	}
+
+	return reflect.StructOf(fields)
+}
+
+func setParquetValue(field reflect.Value, v any) {
+	switch v := v.(type) {
+	case string:
+		field.SetString(v)
+	case int:
+		field.SetInt(int64(v))
+	case float64:
+		field.SetFloat(v)
+	case time.Time:
+		field.Set(reflect.ValueOf(v.UTC()))
+	}
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestWriteTestingPointsParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTestingPoints(&buf, FormatParquet, testingRows); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := f.Schema().Fields()
+	if len(fields) != len(testingColumns) {
+		t.Fatalf("file has %d columns, want %d", len(fields), len(testingColumns))
+	}
+	for i, c := range testingColumns {
+		if fields[i].Name() != c.name {
+			t.Errorf("column %d is %q, want %q", i, fields[i].Name(), c.name)
+		}
+		if fields[i].Optional() != c.optional {
+			t.Errorf("column %q optional = %v, want %v", c.name, fields[i].Optional(), c.optional)
+		}
+	}
+	if lt := fields[len(fields)-1].Type().LogicalType().String(); lt != "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)" {
+		t.Errorf("event_at has logical type %s, want a UTC microsecond timestamp", lt)
+	}
+
+	got := readParquetRows(t, buf.Bytes(), testingColumns)
+	want := [][]any{
+		{"fl-1", 0, 1, "ce", 32, 0.75, 0.5, testingRows[0].EventAt},
+		{"fl-1", 0, 2, "ce", 32, nil, 0.625, testingRows[1].EventAt},
+		{"fl-1", 1, 1, "", 0, nil, nil, testingRows[2].EventAt},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("read back\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWriteParquetRowGroups(t *testing.T) {
+	rows := make([]store.TestingGraphExportRow, parquetRowGroupSize+10)
+	for i := range rows {
+		rows[i] = store.TestingGraphExportRow{ServerRound: i, EventAt: time.Unix(int64(i), 0)}
+		if i%3 == 0 {
+			rows[i].Accuracy = float(float64(i))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTestingPoints(&buf, FormatParquet, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != int64(len(rows)) {
+		t.Fatalf("file has %d rows, want %d", f.NumRows(), len(rows))
+	}
+	if n := len(f.RowGroups()); n != 2 {
+		t.Fatalf("file has %d row groups, want 2", n)
+	}
+
+	got := readParquetRows(t, buf.Bytes(), testingColumns)
+	for _, i := range []int{0, 1, parquetRowGroupSize - 1, parquetRowGroupSize, len(rows) - 1} {
+		if round := got[i][2]; round != i {
+			t.Errorf("server_round of row %d = %v", i, round)
+		}
+
+		var want any
+		if i%3 == 0 {
+			want = float64(i)
+		}
+		if accuracy := got[i][6]; accuracy != want {
+			t.Errorf("accuracy of row %d = %v, want %v", i, accuracy, want)
+		}
+	}
+}
+
+// readParquetRows reads every row of a Parquet file as the values of columns: string, int,
+// float64, time.Time, or nil for a null.
+func readParquetRows(t *testing.T, data []byte, columns []column) [][]any {
+	t.Helper()
+
+	r := parquet.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	var rows [][]any
+	buf := make([]parquet.Row, 1024)
+	for {
+		n, err := r.ReadRows(buf)
+		for _, row := range buf[:n] {
+			values := make([]any, len(columns))
+			for _, v := range row {
+				if v.IsNull() {
+					continue
+				}
+				switch i := v.Column(); columns[i].kind {
+				case kindString:
+					values[i] = string(v.ByteArray())
+				case kindInt:
+					values[i] = int(v.Int32())
+				case kindFloat:
+					values[i] = v.Double()
+				case kindTime:
+					values[i] = time.UnixMicro(v.Int64()).UTC()
+				}
+			}
+			rows = append(rows, values)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TensorBoardFileName returns the conventional name of a TensorBoard event file,
+// which TensorBoard requires to contain "tfevents".
+func TensorBoardFileName(createdAt time.Time, host string) string {
+	return fmt.Sprintf("events.out.tfevents.%d.%s", createdAt.Unix(), host)
+}
+
+// WriteTensorBoard writes the curves of a training as a TensorBoard event file.
+//
+// Each client gets the scalars client/{partition}/train_loss, val_loss, accuracy and
+// epoch_elapsed_time, stepped by the epochs the client trained across all server rounds,
+// and client/{partition}/test_loss and test_accuracy, stepped by server round. The test
+// accuracy is not written as accuracy because that tag already holds the per-epoch curve.
+// The rows must be ordered as returned by the store.
+func WriteTensorBoard(
+	w io.Writer,
+	training []store.TrainingGraphExportRow,
+	testing []store.TestingGraphExportRow,
+) error {
+	tw := &tfRecordWriter{w: w}
+
+	if err := tw.write(tfFileVersionEvent(time.Now())); err != nil {
+		return err
+	}
+
+	// steps counts the epochs written per partition, so rounds continue one curve.
+	steps := make(map[int]int64)
+	for _, r := range training {
+		step := steps[r.PartitionID]
+		steps[r.PartitionID]++
+
+		prefix := fmt.Sprintf("client/%d/", r.PartitionID)
+		event := tfScalarsEvent(r.EventAt, step, []tfScalar{
+			{prefix + "train_loss", r.TrainLoss},
+			{prefix + "val_loss", r.ValLoss},
+			{prefix + "accuracy", r.Accuracy},
+			{prefix + "epoch_elapsed_time", r.EpochElapsedTime},
+		})
+		if err := tw.write(event); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range testing {
+		prefix := fmt.Sprintf("client/%d/", r.PartitionID)
+		event := tfScalarsEvent(r.EventAt, int64(r.ServerRound), []tfScalar{
+			{prefix + "test_loss", r.TestLoss},
+			{prefix + "test_accuracy", r.Accuracy},
+		})
+		if err := tw.write(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tfScalar is one value of an event; a nil value was not reported and is left out.
+type tfScalar struct {
+	tag   string
+	value *float64
+}
+
+// tfFileVersionEvent encodes the Event that starts every event file:
+// Event{wall_time: 1, file_version: 3}.
+func tfFileVersionEvent(t time.Time) []byte {
+	var e []byte
+	e = protowire.AppendTag(e, 1, protowire.Fixed64Type)
+	e = protowire.AppendFixed64(e, math.Float64bits(wallTime(t)))
+	e = protowire.AppendTag(e, 3, protowire.BytesType)
+	e = protowire.AppendString(e, "brain.Event:2")
+	return e
+}
+
+// tfScalarsEvent encodes Event{wall_time: 1, step: 2, summary: 5} where the
+// Summary holds one Value{tag: 1, simple_value: 2} per scalar.
+func tfScalarsEvent(t time.Time, step int64, scalars []tfScalar) []byte {
+	var summary []byte
+	for _, s := range scalars {
+		if s.value == nil {
+			continue
+		}
+
+		var value []byte
+		value = protowire.AppendTag(value, 1, protowire.BytesType)
+		value = protowire.AppendString(value, s.tag)
+		value = protowire.AppendTag(value, 2, protowire.Fixed32Type)
+		value = protowire.AppendFixed32(value, math.Float32bits(float32(*s.value)))
+
+		summary = protowire.AppendTag(summary, 1, protowire.BytesType)
+		summary = protowire.AppendBytes(summary, value)
+	}
+
+	var e []byte
+	e = protowire.AppendTag(e, 1, protowire.Fixed64Type)
+	e = protowire.AppendFixed64(e, math.Float64bits(wallTime(t)))
+	e = protowire.AppendTag(e, 2, protowire.VarintType)
+	e = protowire.AppendVarint(e, uint64(step))
+	e = protowire.AppendTag(e, 5, protowire.BytesType)
+	e = protowire.AppendBytes(e, summary)
+	return e
+}
+
+func wallTime(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// tfRecordWriter frames records as TFRecords:
+// uint64 length, masked CRC of the length, data, masked CRC of the data.
+type tfRecordWriter struct {
+	w io.Writer
+}
+
+func (tw *tfRecordWriter) write(data []byte) error {
+	header := binary.LittleEndian.AppendUint64(nil, uint64(len(data)))
+
+	record := make([]byte, 0, len(header)+len(data)+8)
+	record = append(record, header...)
+	record = binary.LittleEndian.AppendUint32(record, maskedCRC(header))
+	record = append(record, data...)
+	record = binary.LittleEndian.AppendUint32(record, maskedCRC(data))
+
+	_, err := tw.w.Write(record)
+	return err
+}
+
+func maskedCRC(b []byte) uint32 {
+	crc := crc32.Checksum(b, crc32c)
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
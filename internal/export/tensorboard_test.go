@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/store"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestMaskedCRC(t *testing.T) {
+	// 0xe3069283 is the CRC-32C check value of "123456789"; TFRecord masks it by rotating
+	// right by 15 bits and adding 0xa282ead8.
+	if got := crc32.Checksum([]byte("123456789"), crc32c); got != 0xe3069283 {
+		t.Fatalf("CRC-32C = %#x, want 0xe3069283", got)
+	}
+	if got := maskedCRC([]byte("123456789")); got != 0xc78ab0e5 {
+		t.Fatalf("maskedCRC() = %#x, want 0xc78ab0e5", got)
+	}
+}
+
+func TestTFFileVersionEventBytes(t *testing.T) {
+	got := tfFileVersionEvent(time.Unix(1, 500_000_000))
+
+	want := []byte{
+		0x09, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f, // field 1, fixed64: wall_time 1.5
+		0x1a, 13, // field 3, length-delimited: file_version
+	}
+	want = append(want, "brain.Event:2"...)
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("tfFileVersionEvent() = % x, want % x", got, want)
+	}
+}
+
+func TestWriteTensorBoard(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	training := []store.TrainingGraphExportRow{
+		{PartitionID: 0, ServerRound: 1, TrainLoss: float(0.5), ValLoss: float(0.25), Accuracy: float(0.75), EpochElapsedTime: float(2), EventAt: at},
+		{PartitionID: 0, ServerRound: 2, TrainLoss: float(0.125), EventAt: at.Add(time.Second)},
+	}
+	testPoints := []store.TestingGraphExportRow{
+		{PartitionID: 1, ServerRound: 3, TestLoss: float(1.5), Accuracy: nil, EventAt: at.Add(2 * time.Second)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTensorBoard(&buf, training, testPoints); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readTFRecords(t, buf.Bytes())
+	if len(records) != 4 {
+		t.Fatalf("file has %d records, want 4", len(records))
+	}
+
+	if version := decodeTFEvent(t, records[0]).fileVersion; version != "brain.Event:2" {
+		t.Fatalf("first event file_version = %q, want brain.Event:2", version)
+	}
+
+	want := []tfEvent{
+		{
+			wallTime: float64(at.Unix()),
+			step:     0,
+			values: map[string]float32{
+				"client/0/train_loss":         0.5,
+				"client/0/val_loss":           0.25,
+				"client/0/accuracy":           0.75,
+				"client/0/epoch_elapsed_time": 2,
+			},
+		},
+		{
+			// the second round continues the curve; missing metrics are left out
+			wallTime: float64(at.Unix() + 1),
+			step:     1,
+			values:   map[string]float32{"client/0/train_loss": 0.125},
+		},
+		{
+			wallTime: float64(at.Unix() + 2),
+			step:     3,
+			values:   map[string]float32{"client/1/test_loss": 1.5},
+		},
+	}
+	for i, w := range want {
+		if got := decodeTFEvent(t, records[i+1]); !reflect.DeepEqual(got, w) {
+			t.Errorf("event %d = %+v, want %+v", i+1, got, w)
+		}
+	}
+}
+
+// readTFRecords splits a TFRecord file into its records, checking both masked CRCs of each.
+func readTFRecords(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+
+	var records [][]byte
+	for len(data) > 0 {
+		if len(data) < 12 {
+			t.Fatalf("truncated record header: %d bytes", len(data))
+		}
+		header := data[:8]
+		if got, want := binary.LittleEndian.Uint32(data[8:]), maskedCRC(header); got != want {
+			t.Fatalf("record %d: length CRC %#x, want %#x", len(records), got, want)
+		}
+
+		n := int(binary.LittleEndian.Uint64(header))
+		if len(data) < 12+n+4 {
+			t.Fatalf("record %d: truncated data", len(records))
+		}
+		record := data[12 : 12+n]
+		if got, want := binary.LittleEndian.Uint32(data[12+n:]), maskedCRC(record); got != want {
+			t.Fatalf("record %d: data CRC %#x, want %#x", len(records), got, want)
+		}
+
+		records = append(records, record)
+		data = data[12+n+4:]
+	}
+
+	return records
+}
+
+// tfEvent holds the fields of a tensorflow.Event that WriteTensorBoard sets.
+type tfEvent struct {
+	wallTime    float64
+	step        int64
+	fileVersion string
+	values      map[string]float32 // Summary.Value simple_value by tag
+}
+
+func decodeTFEvent(t *testing.T, b []byte) tfEvent {
+	t.Helper()
+
+	var e tfEvent
+	err := eachProtoField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			e.wallTime = math.Float64frombits(binary.LittleEndian.Uint64(v))
+		case num == 2 && typ == protowire.VarintType:
+			step, _ := protowire.ConsumeVarint(v)
+			e.step = int64(step)
+		case num == 3 && typ == protowire.BytesType:
+			e.fileVersion = string(v)
+		case num == 5 && typ == protowire.BytesType:
+			e.values = make(map[string]float32)
+			return eachProtoField(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				if num != 1 || typ != protowire.BytesType {
+					return fmt.Errorf("unexpected Summary field %d", num)
+				}
+				return decodeTFSummaryValue(v, e.values)
+			})
+		default:
+			return fmt.Errorf("unexpected Event field %d of type %d", num, typ)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func decodeTFSummaryValue(b []byte, values map[string]float32) error {
+	var tag string
+	var value float32
+	err := eachProtoField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			tag = string(v)
+		case num == 2 && typ == protowire.Fixed32Type:
+			value = math.Float32frombits(binary.LittleEndian.Uint32(v))
+		default:
+			return fmt.Errorf("unexpected Summary.Value field %d of type %d", num, typ)
+		}
+		return nil
+	})
+	values[tag] = value
+	return err
+}
+
+// eachProtoField calls fn with every field of a protobuf message. v is the payload of
+// length-delimited fields and the raw encoded value otherwise.
+func eachProtoField(b []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		size := protowire.ConsumeFieldValue(num, typ, b)
+		if size < 0 {
+			return protowire.ParseError(size)
+		}
+
+		v := b[:size]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		if err := fn(num, typ, v); err != nil {
+			return err
+		}
+		b = b[size:]
+	}
+
+	return nil
+}
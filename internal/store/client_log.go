@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,35 +19,63 @@ type ClientLog struct {
 }
 
 type ClientLogStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewClientLogStore(db *sql.DB) *ClientLogStore {
+func NewClientLogStore(db DBTX) *ClientLogStore {
 	return &ClientLogStore{db: db}
 }
 
+// Create inserts log unless the client already has the same line at the same time.
 func (s *ClientLogStore) Create(ctx context.Context, log ClientLog) error {
 	query := `
 		INSERT INTO client_logs (client_id, text, client_output_at)
 		VALUES ($1, $2, $3)
-		RETURNING id, created_at
+		ON CONFLICT (client_id, client_output_at, md5(text)) DO NOTHING
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(
+	_, err := s.db.ExecContext(
 		ctx,
 		query,
 		log.ClientID,
 		log.Text,
 		log.ClientOutputAt,
-	).Scan(
-		&log.ID,
-		&log.CreatedAt,
 	)
-	if err != nil {
-		return err
+
+	return err
+}
+
+// maxClientLogsPerInsert keeps a multi-row INSERT well below the 65535 bind parameter limit.
+const maxClientLogsPerInsert = 1000
+
+// CreateBatch inserts logs with multi-row INSERTs of up to maxClientLogsPerInsert rows.
+// Like Create, it skips lines the client already has.
+func (s *ClientLogStore) CreateBatch(ctx context.Context, logs []ClientLog) error {
+	for start := 0; start < len(logs); start += maxClientLogsPerInsert {
+		chunk := logs[start:min(start+maxClientLogsPerInsert, len(logs))]
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO client_logs (client_id, text, client_output_at) VALUES ")
+
+		args := make([]any, 0, len(chunk)*3)
+		for i, log := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3)
+			args = append(args, log.ClientID, log.Text, log.ClientOutputAt)
+		}
+		query.WriteString(" ON CONFLICT (client_id, client_output_at, md5(text)) DO NOTHING")
+
+		queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		_, err := s.db.ExecContext(queryCtx, query.String(), args...)
+		cancel()
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -97,3 +127,117 @@ func (s *ClientLogStore) GetByClientID(ctx context.Context, clientID uuid.UUID)
 
 	return logs, nil
 }
+
+// ClientLogCursor is the position of a log line in (client_output_at, id) order.
+type ClientLogCursor struct {
+	ClientOutputAt time.Time
+	ID             uuid.UUID
+}
+
+// ClientLogQuery narrows down ClientLogStore.Query.
+type ClientLogQuery struct {
+	ClientID uuid.UUID
+	Since    *time.Time // inclusive lower bound on client_output_at
+	Until    *time.Time // exclusive upper bound on client_output_at
+	Search   string     // empty matches every line
+	FullText bool       // match Search as a full-text query instead of a substring
+	// Cursor is the last line of the previous page. Lines after it are returned,
+	// or lines before it if Tail is set.
+	Cursor *ClientLogCursor
+	// Tail returns the last Limit lines instead of the first ones.
+	Tail  bool
+	Limit int
+}
+
+// Query returns up to q.Limit log lines of a client matching q, oldest first.
+func (s *ClientLogStore) Query(ctx context.Context, q ClientLogQuery) ([]ClientLog, error) {
+	// Tail mode walks the index backwards and reverses the page afterwards.
+	order, cmp := "ASC", ">"
+	if q.Tail {
+		order, cmp = "DESC", "<"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			client_id,
+			text,
+			client_output_at,
+			created_at
+		FROM client_logs
+		WHERE client_id = $1
+			AND ($2::timestamptz IS NULL OR client_output_at >= $2)
+			AND ($3::timestamptz IS NULL OR client_output_at < $3)
+			AND ($4::timestamptz IS NULL OR (client_output_at, id) %[1]s ($4, $5::uuid))
+			AND ($6 = '' OR text ILIKE '%%' || $6 || '%%')
+			AND ($7 = '' OR to_tsvector('simple', text) @@ websearch_to_tsquery('simple', $7))
+		ORDER BY client_output_at %[2]s, id %[2]s
+		LIMIT $8
+	`, cmp, order)
+
+	var cursorAt *time.Time
+	var cursorID *uuid.UUID
+	if q.Cursor != nil {
+		cursorAt, cursorID = &q.Cursor.ClientOutputAt, &q.Cursor.ID
+	}
+
+	var substring, fullText string
+	if q.FullText {
+		fullText = q.Search
+	} else {
+		substring = escapeLike(q.Search)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		q.ClientID,
+		q.Since,
+		q.Until,
+		cursorAt,
+		cursorID,
+		substring,
+		fullText,
+		q.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []ClientLog{}
+
+	for rows.Next() {
+		var l ClientLog
+		err := rows.Scan(
+			&l.ID,
+			&l.ClientID,
+			&l.Text,
+			&l.ClientOutputAt,
+			&l.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if q.Tail {
+		slices.Reverse(logs)
+	}
+
+	return logs, nil
+}
+
+// escapeLike escapes the ILIKE wildcards in s, so it matches as a literal substring.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
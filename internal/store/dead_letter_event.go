@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeadLetterStageParse     = "parse"
+	DeadLetterStageHandle    = "handle"
+	DeadLetterStageTruncated = "truncated" // the line exceeded the reader limit and cannot be re-driven
+)
+
+// DeadLetterEvent is a log event that could not be parsed or handled.
+// RawLine holds the JSON message of the log line, so the event can be re-driven later.
+type DeadLetterEvent struct {
+	ID            uuid.UUID  `json:"id"`
+	Stage         string     `json:"stage"`
+	RawLine       string     `json:"raw_line"`
+	PodName       string     `json:"pod_name"`
+	NodeName      string     `json:"node_name"`
+	Component     string     `json:"component"`
+	Event         string     `json:"event"`
+	LogTimestamp  time.Time  `json:"log_timestamp"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastAttemptAt time.Time  `json:"last_attempt_at"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+}
+
+// DeadLetterFilter narrows down DeadLetterEventStore.List.
+type DeadLetterFilter struct {
+	Stage           string // empty matches every stage
+	Event           string // empty matches every event
+	IncludeResolved bool
+	Limit           int
+}
+
+type DeadLetterEventStore struct {
+	db DBTX
+}
+
+func NewDeadLetterEventStore(db DBTX) *DeadLetterEventStore {
+	return &DeadLetterEventStore{db: db}
+}
+
+func (s *DeadLetterEventStore) Create(ctx context.Context, e DeadLetterEvent) error {
+	query := `
+		INSERT INTO dead_letter_events (
+			stage,
+			raw_line,
+			pod_name,
+			node_name,
+			component,
+			event,
+			log_timestamp,
+			error
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		e.Stage,
+		e.RawLine,
+		e.PodName,
+		e.NodeName,
+		e.Component,
+		e.Event,
+		e.LogTimestamp,
+		e.Error,
+	).Scan(
+		&e.ID,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *DeadLetterEventStore) GetByID(ctx context.Context, id uuid.UUID) (DeadLetterEvent, error) {
+	query := `
+		SELECT
+			id,
+			stage,
+			raw_line,
+			pod_name,
+			node_name,
+			component,
+			event,
+			log_timestamp,
+			error,
+			attempts,
+			created_at,
+			last_attempt_at,
+			resolved_at
+		FROM dead_letter_events
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var e DeadLetterEvent
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&e.ID,
+		&e.Stage,
+		&e.RawLine,
+		&e.PodName,
+		&e.NodeName,
+		&e.Component,
+		&e.Event,
+		&e.LogTimestamp,
+		&e.Error,
+		&e.Attempts,
+		&e.CreatedAt,
+		&e.LastAttemptAt,
+		&e.ResolvedAt,
+	)
+	if err != nil {
+		return DeadLetterEvent{}, err
+	}
+
+	return e, nil
+}
+
+// List returns dead letters matching filter, oldest first.
+func (s *DeadLetterEventStore) List(ctx context.Context, filter DeadLetterFilter) ([]DeadLetterEvent, error) {
+	query := `
+		SELECT
+			id,
+			stage,
+			raw_line,
+			pod_name,
+			node_name,
+			component,
+			event,
+			log_timestamp,
+			error,
+			attempts,
+			created_at,
+			last_attempt_at,
+			resolved_at
+		FROM dead_letter_events
+		WHERE ($1 = '' OR stage = $1)
+			AND ($2 = '' OR event = $2)
+			AND ($3 OR resolved_at IS NULL)
+		ORDER BY created_at ASC
+		LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		filter.Stage,
+		filter.Event,
+		filter.IncludeResolved,
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []DeadLetterEvent
+
+	for rows.Next() {
+		var e DeadLetterEvent
+		err := rows.Scan(
+			&e.ID,
+			&e.Stage,
+			&e.RawLine,
+			&e.PodName,
+			&e.NodeName,
+			&e.Component,
+			&e.Event,
+			&e.LogTimestamp,
+			&e.Error,
+			&e.Attempts,
+			&e.CreatedAt,
+			&e.LastAttemptAt,
+			&e.ResolvedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkResolved records a successful re-drive of a dead letter.
+func (s *DeadLetterEventStore) MarkResolved(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE dead_letter_events
+		SET resolved_at = now(),
+			attempts = attempts + 1,
+			last_attempt_at = now()
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// RecordFailedAttempt records a failed re-drive of a dead letter with its new error.
+func (s *DeadLetterEventStore) RecordFailedAttempt(ctx context.Context, id uuid.UUID, errMsg string) error {
+	query := `
+		UPDATE dead_letter_events
+		SET error = $2,
+			attempts = attempts + 1,
+			last_attempt_at = now()
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, id, errMsg)
+	return err
+}
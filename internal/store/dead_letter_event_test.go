@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeadLetterEventStoreCreateTruncated(t *testing.T) {
+	db := openTestDB(t)
+	s := NewDeadLetterEventStore(db)
+
+	pod := "dead-letter-test-" + uuid.NewString()
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM dead_letter_events WHERE pod_name = $1`, pod); err != nil {
+			t.Errorf("delete test dead letters: %v", err)
+		}
+	})
+
+	ts := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := s.Create(context.Background(), DeadLetterEvent{
+		Stage:        DeadLetterStageTruncated,
+		RawLine:      `{"event":"READLINE","payload":`,
+		PodName:      pod,
+		NodeName:     "node",
+		Component:    "clientapp",
+		LogTimestamp: ts,
+		Error:        "line exceeds 16 bytes",
+	})
+	if err != nil {
+		t.Fatalf("create truncated dead letter: %v", err)
+	}
+
+	var id uuid.UUID
+	if err := db.QueryRow(`SELECT id FROM dead_letter_events WHERE pod_name = $1`, pod).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	dl, err := s.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Stage != DeadLetterStageTruncated {
+		t.Errorf("stage = %q, want %q", dl.Stage, DeadLetterStageTruncated)
+	}
+	if !dl.LogTimestamp.Equal(ts) {
+		t.Errorf("log timestamp = %v, want %v", dl.LogTimestamp, ts)
+	}
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	_ "github.com/lib/pq"
+)
+
+// ensureConcurrency is the number of goroutines racing on one Ensure call.
+const ensureConcurrency = 32
+
+// openTestDB connects to the migrated database in TEST_DB_ADDR and skips the test without one.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	addr := os.Getenv("TEST_DB_ADDR")
+	if addr == "" {
+		t.Skip("TEST_DB_ADDR is not set")
+	}
+
+	db, err := sql.Open("postgres", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(ensureConcurrency)
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("ping test database: %v", err)
+	}
+
+	return db
+}
+
+// newTestTrainingID returns a unique fl_training_id whose rows are deleted after the test.
+func newTestTrainingID(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	id := "ensure-test-" + uuid.NewString()
+	t.Cleanup(func() {
+		if _, err := db.Exec(`DELETE FROM fl_trainings WHERE fl_training_id = $1`, id); err != nil {
+			t.Errorf("delete test training: %v", err)
+		}
+	})
+
+	return id
+}
+
+// ensureConcurrently runs ensure from ensureConcurrency goroutines at once and checks that
+// every call succeeds with the same row.
+func ensureConcurrently(t *testing.T, ensure func(context.Context) (uuid.UUID, error)) {
+	t.Helper()
+
+	var (
+		start = make(chan struct{})
+		wg    sync.WaitGroup
+		ids   = make([]uuid.UUID, ensureConcurrency)
+		errs  = make([]error, ensureConcurrency)
+	)
+
+	for i := range ensureConcurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ids[i], errs[i] = ensure(context.Background())
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("goroutine %d: %v", i, err)
+		}
+		if ids[i] != ids[0] {
+			t.Fatalf("goroutine %d got row %s, goroutine 0 got %s", i, ids[i], ids[0])
+		}
+	}
+}
+
+func TestFLTrainingStoreEnsureConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	s := NewFLTrainingStore(db)
+	flTrainingID := newTestTrainingID(t, db)
+
+	ensureConcurrently(t, func(ctx context.Context) (uuid.UUID, error) {
+		tr, err := s.Ensure(ctx, flTrainingID)
+		return tr.ID, err
+	})
+}
+
+func TestFLTrainingClientStoreEnsureConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	flTrainingID := newTestTrainingID(t, db)
+	if _, err := NewFLTrainingStore(db).Ensure(context.Background(), flTrainingID); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewFLTrainingClientStore(db)
+	ensureConcurrently(t, func(ctx context.Context) (uuid.UUID, error) {
+		c, err := s.EnsureByFLTrainingIDAndPartitionID(ctx, flTrainingID, 0, "node", "pod", "IDLE")
+		return c.ID, err
+	})
+}
+
+func TestFLTrainingServerStoreEnsureConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	flTrainingID := newTestTrainingID(t, db)
+	if _, err := NewFLTrainingStore(db).Ensure(context.Background(), flTrainingID); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewFLTrainingServerStore(db)
+	ensureConcurrently(t, func(ctx context.Context) (uuid.UUID, error) {
+		srv, err := s.EnsureByFLTrainingID(ctx, flTrainingID, "node", "pod")
+		return srv.ID, err
+	})
+}
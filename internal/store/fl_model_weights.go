@@ -1,39 +1,293 @@
 package store
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
-	"database/sql"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/blob"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/weights"
+	"github.com/google/uuid"
 )
 
+// FLModelWeights is the metadata of the model weights of one server round.
+// The weights themselves are a gzip-compressed blob keyed by SHA256, the hash of the
+// uncompressed weights as written by weights.Encode.
+type FLModelWeights struct {
+	ID           uuid.UUID           `json:"id"`
+	FLTrainingID string              `json:"fl_training_id"`
+	ServerRound  int                 `json:"server_round"`
+	SHA256       string              `json:"sha256"`
+	DType        string              `json:"dtype"`
+	Layers       []weights.LayerMeta `json:"layers"`
+	RawSize      int64               `json:"raw_size"`    // bytes of the uncompressed weights
+	StoredSize   int64               `json:"stored_size"` // bytes of the compressed blob
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
 type FLModelWeightsStore struct {
-	db *sql.DB
+	db    DBTX
+	blobs blob.Store
 }
 
-func NewFLModelWeightsStore(db *sql.DB) *FLModelWeightsStore {
-	return &FLModelWeightsStore{db: db}
+func NewFLModelWeightsStore(db DBTX, blobs blob.Store) *FLModelWeightsStore {
+	return &FLModelWeightsStore{db: db, blobs: blobs}
 }
 
-// Upsert inserts a new row for (fl_training_id, server_round) or updates payload if it already exists.
+// Upsert stores the weights of (fl_training_id, server_round), replacing earlier weights of that round.
+// The blob is written before the row and is not part of the transaction; a blob left behind by a
+// rolled back transaction is harmless, since the same weights map to the same key.
 func (s *FLModelWeightsStore) Upsert(
 	ctx context.Context,
 	flTrainingID string,
 	serverRound int,
-	payload []byte,
-) error {
+	layers []weights.Layer,
+) (FLModelWeights, error) {
+	dtype := weights.DTypeOf(layers)
+	raw, err := weights.Encode(layers, dtype)
+	if err != nil {
+		return FLModelWeights{}, err
+	}
+	sum := sha256.Sum256(raw)
+
+	w := FLModelWeights{
+		FLTrainingID: flTrainingID,
+		ServerRound:  serverRound,
+		SHA256:       hex.EncodeToString(sum[:]),
+		DType:        dtype,
+		Layers:       weights.Meta(layers),
+		RawSize:      int64(len(raw)),
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(raw); err != nil {
+		return FLModelWeights{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return FLModelWeights{}, err
+	}
+	w.StoredSize = int64(compressed.Len())
+
+	exists, err := s.blobs.Exists(ctx, w.SHA256)
+	if err != nil {
+		return FLModelWeights{}, fmt.Errorf("check weights blob: %w", err)
+	}
+	if !exists {
+		if err := s.blobs.Put(ctx, w.SHA256, &compressed, w.StoredSize); err != nil {
+			return FLModelWeights{}, fmt.Errorf("store weights blob: %w", err)
+		}
+	}
+
+	layersJSON, err := json.Marshal(w.Layers)
+	if err != nil {
+		return FLModelWeights{}, err
+	}
+
 	query := `
 		INSERT INTO fl_model_weights (
 			fl_training_id,
 			server_round,
-			payload
+			sha256,
+			dtype,
+			layers,
+			raw_size,
+			stored_size
 		)
-		VALUES ($1, $2, $3)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (fl_training_id, server_round)
-		DO UPDATE SET payload = EXCLUDED.payload
+		DO UPDATE SET
+			sha256 = EXCLUDED.sha256,
+			dtype = EXCLUDED.dtype,
+			layers = EXCLUDED.layers,
+			raw_size = EXCLUDED.raw_size,
+			stored_size = EXCLUDED.stored_size,
+			payload = NULL
+		RETURNING id, created_at
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, flTrainingID, serverRound, payload)
-	return err
+	err = s.db.QueryRowContext(
+		ctx,
+		query,
+		w.FLTrainingID,
+		w.ServerRound,
+		w.SHA256,
+		w.DType,
+		layersJSON,
+		w.RawSize,
+		w.StoredSize,
+	).Scan(
+		&w.ID,
+		&w.CreatedAt,
+	)
+	if err != nil {
+		return FLModelWeights{}, err
+	}
+
+	return w, nil
+}
+
+// Rows written before weights moved to blobs keep their layers in payload and have no sha256;
+// the read queries report them with an empty SHA256 and no layer metadata.
+const flModelWeightsColumns = `
+	id,
+	fl_training_id,
+	server_round,
+	COALESCE(sha256, ''),
+	COALESCE(dtype, ''),
+	COALESCE(layers, '[]'::jsonb),
+	COALESCE(raw_size, 0),
+	COALESCE(stored_size, 0),
+	created_at
+`
+
+// ListByFLTrainingID returns the weights metadata of every round of a training, ordered by round.
+func (s *FLModelWeightsStore) ListByFLTrainingID(ctx context.Context, flTrainingID string) ([]FLModelWeights, error) {
+	query := `
+		SELECT` + flModelWeightsColumns + `
+		FROM fl_model_weights
+		WHERE fl_training_id = $1
+		ORDER BY server_round
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, flTrainingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []FLModelWeights
+	for rows.Next() {
+		w, err := scanFLModelWeights(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// GetByRound returns the weights metadata of one server round.
+func (s *FLModelWeightsStore) GetByRound(ctx context.Context, flTrainingID string, serverRound int) (FLModelWeights, error) {
+	query := `
+		SELECT` + flModelWeightsColumns + `
+		FROM fl_model_weights
+		WHERE fl_training_id = $1 AND server_round = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return scanFLModelWeights(s.db.QueryRowContext(ctx, query, flTrainingID, serverRound))
+}
+
+// Load reads the layers of w. Blob weights are checked against their SHA256;
+// rows from before blobs are parsed from their JSON payload.
+func (s *FLModelWeightsStore) Load(ctx context.Context, w FLModelWeights) ([]weights.Layer, error) {
+	if w.SHA256 == "" {
+		return s.loadPayload(ctx, w.ID)
+	}
+
+	rc, err := s.blobs.Get(ctx, w.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("open weights blob: %w", err)
+	}
+	defer rc.Close()
+
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read weights blob: %w", err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read weights blob: %w", err)
+	}
+
+	sum := sha256.Sum256(raw)
+	if got := hex.EncodeToString(sum[:]); got != w.SHA256 {
+		return nil, fmt.Errorf("weights blob checksum mismatch: got %s, want %s", got, w.SHA256)
+	}
+
+	return weights.Decode(bytes.NewReader(raw), w.Layers, w.DType)
+}
+
+// loadPayload parses the layers of a row written before weights moved to the blob store.
+func (s *FLModelWeightsStore) loadPayload(ctx context.Context, id uuid.UUID) ([]weights.Layer, error) {
+	query := `
+		SELECT payload
+		FROM fl_model_weights
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var payload []byte
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&payload); err != nil {
+		return nil, err
+	}
+
+	// payload is the whole MODEL_WEIGHTS event payload; the layers are one of its fields
+	var p struct {
+		Layers json.RawMessage `json:"layers"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("decode weights payload: %w", err)
+	}
+	if len(p.Layers) == 0 {
+		return nil, fmt.Errorf("weights payload has no layers")
+	}
+
+	return weights.ParseJSON(p.Layers)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFLModelWeights(row rowScanner) (FLModelWeights, error) {
+	var (
+		w          FLModelWeights
+		layersJSON []byte
+	)
+
+	err := row.Scan(
+		&w.ID,
+		&w.FLTrainingID,
+		&w.ServerRound,
+		&w.SHA256,
+		&w.DType,
+		&layersJSON,
+		&w.RawSize,
+		&w.StoredSize,
+		&w.CreatedAt,
+	)
+	if err != nil {
+		return FLModelWeights{}, err
+	}
+
+	if err := json.Unmarshal(layersJSON, &w.Layers); err != nil {
+		return FLModelWeights{}, fmt.Errorf("decode weights layers: %w", err)
+	}
+
+	return w, nil
 }
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,10 +18,10 @@ type FLTraining struct {
 }
 
 type FLTrainingStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewFLTrainingStore(db *sql.DB) *FLTrainingStore {
+func NewFLTrainingStore(db DBTX) *FLTrainingStore {
 	return &FLTrainingStore{db: db}
 }
 
@@ -128,20 +129,40 @@ func (s *FLTrainingStore) GetByFLTrainingID(ctx context.Context, flTrainingID st
 }
 
 // Ensure returns the FLTraining row for the given ID, creating it if it does not exist.
+// It is safe to call concurrently: the insert never fails on the unique constraint, and
+// if another caller created the row first it is read back instead.
 func (s *FLTrainingStore) Ensure(ctx context.Context, flTrainingID string) (FLTraining, error) {
-	f, err := s.GetByFLTrainingID(ctx, flTrainingID)
-	if err == nil {
-		return f, nil
-	}
-	if err != sql.ErrNoRows {
-		return FLTraining{}, err
-	}
+	query := `
+		INSERT INTO fl_trainings (fl_training_id)
+		VALUES ($1)
+		ON CONFLICT (fl_training_id) DO NOTHING
+		RETURNING
+			id,
+			fl_training_id,
+			current_server_round,
+			total_server_round,
+			created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
 
-	if err := s.Create(ctx, FLTraining{FLTrainingID: flTrainingID}); err != nil {
+	var f FLTraining
+	err := s.db.QueryRowContext(ctx, query, flTrainingID).Scan(
+		&f.ID,
+		&f.FLTrainingID,
+		&f.CurrentServerRound,
+		&f.TotalServerRound,
+		&f.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.GetByFLTrainingID(ctx, flTrainingID)
+	}
+	if err != nil {
 		return FLTraining{}, err
 	}
 
-	return s.GetByFLTrainingID(ctx, flTrainingID)
+	return f, nil
 }
 
 func (s *FLTrainingStore) UpdateCurrentServerRound(
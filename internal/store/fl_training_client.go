@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,10 +21,10 @@ type FLTrainingClient struct {
 }
 
 type FLTrainingClientStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewFLTrainingClientStore(db *sql.DB) *FLTrainingClientStore {
+func NewFLTrainingClientStore(db DBTX) *FLTrainingClientStore {
 	return &FLTrainingClientStore{db: db}
 }
 
@@ -92,6 +93,95 @@ func (s *FLTrainingClientStore) GetAll(ctx context.Context) ([]FLTrainingClient,
 	return clients, nil
 }
 
+func (s *FLTrainingClientStore) GetByFLTrainingID(ctx context.Context, flTrainingID string) ([]FLTrainingClient, error) {
+	query := `
+		SELECT
+			id,
+			fl_training_id,
+			partition_id,
+			node_name,
+			pod_name,
+			state,
+			created_at,
+			last_log_read
+		FROM training_clients
+		WHERE fl_training_id = $1
+		ORDER BY partition_id ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, flTrainingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []FLTrainingClient
+
+	for rows.Next() {
+		var c FLTrainingClient
+		err := rows.Scan(
+			&c.ID,
+			&c.FLTrainingID,
+			&c.PartitionID,
+			&c.NodeName,
+			&c.PodName,
+			&c.State,
+			&c.CreatedAt,
+			&c.LastLogRead,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (s *FLTrainingClientStore) GetByID(ctx context.Context, id uuid.UUID) (FLTrainingClient, error) {
+	query := `
+		SELECT
+			id,
+			fl_training_id,
+			partition_id,
+			node_name,
+			pod_name,
+			state,
+			created_at,
+			last_log_read
+		FROM training_clients
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var c FLTrainingClient
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&c.ID,
+		&c.FLTrainingID,
+		&c.PartitionID,
+		&c.NodeName,
+		&c.PodName,
+		&c.State,
+		&c.CreatedAt,
+		&c.LastLogRead,
+	)
+	if err != nil {
+		return FLTrainingClient{}, err
+	}
+
+	return c, nil
+}
+
 func (s *FLTrainingClientStore) Create(ctx context.Context, c FLTrainingClient) error {
 	query := `
 		INSERT INTO training_clients (
@@ -168,6 +258,9 @@ func (s *FLTrainingClientStore) GetByFLTrainingIDAndPartitionID(
 	return c, nil
 }
 
+// EnsureByFLTrainingIDAndPartitionID returns the client of a training/partition or creates it
+// with the provided node/pod and initial state. It is safe to call concurrently: the insert never
+// fails on the unique constraint, and if another caller created the row first it is read back instead.
 func (s *FLTrainingClientStore) EnsureByFLTrainingIDAndPartitionID(
 	ctx context.Context,
 	flTrainingID string,
@@ -176,30 +269,62 @@ func (s *FLTrainingClientStore) EnsureByFLTrainingIDAndPartitionID(
 	podName string,
 	state string,
 ) (FLTrainingClient, error) {
-	c, err := s.GetByFLTrainingIDAndPartitionID(ctx, flTrainingID, partitionID)
-	if err == nil {
-		return c, nil
-	}
-	if err != sql.ErrNoRows {
-		return FLTrainingClient{}, err
-	}
+	query := `
+		INSERT INTO training_clients (
+			fl_training_id,
+			partition_id,
+			node_name,
+			pod_name,
+			state,
+			last_log_read
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (fl_training_id, partition_id) DO NOTHING
+		RETURNING
+			id,
+			fl_training_id,
+			partition_id,
+			node_name,
+			pod_name,
+			state,
+			created_at,
+			last_log_read
+	`
 
-	newClient := FLTrainingClient{
-		FLTrainingID: flTrainingID,
-		PartitionID:  partitionID,
-		NodeName:     nodeName,
-		PodName:      podName,
-		State:        state,
-		LastLogRead:  time.Time{},
-	}
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
 
-	if err := s.Create(ctx, newClient); err != nil {
+	var c FLTrainingClient
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		flTrainingID,
+		partitionID,
+		nodeName,
+		podName,
+		state,
+		time.Time{},
+	).Scan(
+		&c.ID,
+		&c.FLTrainingID,
+		&c.PartitionID,
+		&c.NodeName,
+		&c.PodName,
+		&c.State,
+		&c.CreatedAt,
+		&c.LastLogRead,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.GetByFLTrainingIDAndPartitionID(ctx, flTrainingID, partitionID)
+	}
+	if err != nil {
 		return FLTrainingClient{}, err
 	}
 
-	return s.GetByFLTrainingIDAndPartitionID(ctx, flTrainingID, partitionID)
+	return c, nil
 }
 
+// UpdateLastLogRead advances last_log_read to t; it never moves the marker backwards.
 func (s *FLTrainingClientStore) UpdateLastLogRead(
 	ctx context.Context,
 	flTrainingID string,
@@ -208,7 +333,7 @@ func (s *FLTrainingClientStore) UpdateLastLogRead(
 ) error {
 	query := `
 		UPDATE training_clients
-		SET last_log_read = $3
+		SET last_log_read = GREATEST(last_log_read, $3)
 		WHERE fl_training_id = $1 AND partition_id = $2
 	`
 
@@ -218,3 +343,48 @@ func (s *FLTrainingClientStore) UpdateLastLogRead(
 	_, err := s.db.ExecContext(ctx, query, flTrainingID, partitionID, t)
 	return err
 }
+
+// ClientStateCount is the number of clients of a training in one state.
+type ClientStateCount struct {
+	FLTrainingID string `json:"fl_training_id"`
+	State        string `json:"state"`
+	Count        int    `json:"count"`
+}
+
+// CountByState returns the number of clients per training and state.
+func (s *FLTrainingClientStore) CountByState(ctx context.Context) ([]ClientStateCount, error) {
+	query := `
+		SELECT
+			fl_training_id,
+			state,
+			COUNT(*)
+		FROM training_clients
+		GROUP BY fl_training_id, state
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ClientStateCount
+
+	for rows.Next() {
+		var c ClientStateCount
+		if err := rows.Scan(&c.FLTrainingID, &c.State, &c.Count); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,10 +19,10 @@ type FLTrainingServer struct {
 }
 
 type FLTrainingServerStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewFLTrainingServerStore(db *sql.DB) *FLTrainingServerStore {
+func NewFLTrainingServerStore(db DBTX) *FLTrainingServerStore {
 	return &FLTrainingServerStore{db: db}
 }
 
@@ -94,32 +95,59 @@ func (s *FLTrainingServerStore) Create(ctx context.Context, srv FLTrainingServer
 }
 
 // EnsureByFLTrainingID returns the server row for a training or creates a new one with the provided node/pod.
+// It is safe to call concurrently: the insert never fails on the unique constraint, and
+// if another caller created the row first it is read back instead.
 func (s *FLTrainingServerStore) EnsureByFLTrainingID(
 	ctx context.Context,
 	flTrainingID string,
 	nodeName string,
 	podName string,
 ) (FLTrainingServer, error) {
-	srv, err := s.GetByFLTrainingID(ctx, flTrainingID)
-	if err == nil {
-		return srv, nil
-	}
-	if err != sql.ErrNoRows {
-		return FLTrainingServer{}, err
-	}
+	query := `
+		INSERT INTO training_servers (
+			fl_training_id,
+			node_name,
+			pod_name,
+			last_log_read
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (fl_training_id) DO NOTHING
+		RETURNING
+			id,
+			fl_training_id,
+			node_name,
+			pod_name,
+			created_at,
+			last_log_read
+	`
 
-	newSrv := FLTrainingServer{
-		FLTrainingID: flTrainingID,
-		NodeName:     nodeName,
-		PodName:      podName,
-		LastLogRead:  time.Time{},
-	}
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
 
-	if err := s.Create(ctx, newSrv); err != nil {
+	var srv FLTrainingServer
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		flTrainingID,
+		nodeName,
+		podName,
+		time.Time{},
+	).Scan(
+		&srv.ID,
+		&srv.FLTrainingID,
+		&srv.NodeName,
+		&srv.PodName,
+		&srv.CreatedAt,
+		&srv.LastLogRead,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.GetByFLTrainingID(ctx, flTrainingID)
+	}
+	if err != nil {
 		return FLTrainingServer{}, err
 	}
 
-	return s.GetByFLTrainingID(ctx, flTrainingID)
+	return srv, nil
 }
 
 func (s *FLTrainingServerStore) UpdateLastLogRead(
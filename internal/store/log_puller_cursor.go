@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// LogPullerCursor marks the last log line read from one container of a pod,
+// so the log puller can resume there after a restart.
+type LogPullerCursor struct {
+	PodUID        string    `json:"pod_uid"`
+	Container     string    `json:"container"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"pod_name"`
+	LastTimestamp time.Time `json:"last_timestamp"`
+	LastLineHash  string    `json:"last_line_hash"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type LogPullerCursorStore struct {
+	db DBTX
+}
+
+func NewLogPullerCursorStore(db DBTX) *LogPullerCursorStore {
+	return &LogPullerCursorStore{db: db}
+}
+
+func (s *LogPullerCursorStore) Get(ctx context.Context, podUID string, container string) (LogPullerCursor, error) {
+	query := `
+		SELECT
+			pod_uid,
+			container,
+			namespace,
+			pod_name,
+			last_timestamp,
+			last_line_hash,
+			updated_at
+		FROM log_puller_cursors
+		WHERE pod_uid = $1 AND container = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var c LogPullerCursor
+	err := s.db.QueryRowContext(ctx, query, podUID, container).Scan(
+		&c.PodUID,
+		&c.Container,
+		&c.Namespace,
+		&c.PodName,
+		&c.LastTimestamp,
+		&c.LastLineHash,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return LogPullerCursor{}, err
+	}
+
+	return c, nil
+}
+
+// Upsert stores the cursor for (pod_uid, container). The timestamp never moves backwards.
+func (s *LogPullerCursorStore) Upsert(ctx context.Context, c LogPullerCursor) error {
+	query := `
+		INSERT INTO log_puller_cursors (
+			pod_uid,
+			container,
+			namespace,
+			pod_name,
+			last_timestamp,
+			last_line_hash
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (pod_uid, container)
+		DO UPDATE SET
+			last_timestamp = EXCLUDED.last_timestamp,
+			last_line_hash = EXCLUDED.last_line_hash,
+			updated_at = now()
+		WHERE log_puller_cursors.last_timestamp <= EXCLUDED.last_timestamp
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		c.PodUID,
+		c.Container,
+		c.Namespace,
+		c.PodName,
+		c.LastTimestamp,
+		c.LastLineHash,
+	)
+	return err
+}
+
+// DeleteByPodUID removes the cursors of every container of a pod.
+func (s *LogPullerCursorStore) DeleteByPodUID(ctx context.Context, podUID string) error {
+	query := `
+		DELETE FROM log_puller_cursors
+		WHERE pod_uid = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, podUID)
+	return err
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// QueryObserver is told the duration and error of every query the stores run, labeled with
+// the store method that ran it, e.g. "FLTrainingStore.GetAll".
+type QueryObserver interface {
+	ObserveQuery(method string, d time.Duration, err error)
+}
+
+// observedDB reports every query run on db to observer.
+type observedDB struct {
+	db       DBTX
+	observer QueryObserver
+}
+
+func (o observedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	method, start := queryMethod(), time.Now()
+	res, err := o.db.ExecContext(ctx, query, args...)
+	o.observer.ObserveQuery(method, time.Since(start), err)
+	return res, err
+}
+
+// QueryContext only measures the time until the first rows are available.
+func (o observedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	method, start := queryMethod(), time.Now()
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	o.observer.ObserveQuery(method, time.Since(start), err)
+	return rows, err
+}
+
+func (o observedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	method, start := queryMethod(), time.Now()
+	row := o.db.QueryRowContext(ctx, query, args...)
+	o.observer.ObserveQuery(method, time.Since(start), row.Err())
+	return row
+}
+
+// queryMethod returns the name of the store method calling an observedDB method,
+// without its package and receiver pointer.
+func queryMethod() string {
+	var pcs [1]uintptr
+	// skip runtime.Callers, queryMethod and the observedDB method
+	if runtime.Callers(3, pcs[:]) == 0 {
+		return "unknown"
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	name = strings.TrimPrefix(name, "store.")
+
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
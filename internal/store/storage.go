@@ -6,6 +6,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/blob"
+	"github.com/KanathipP/KubeLogPullStoreGopher/internal/weights"
 	"github.com/google/uuid"
 )
 
@@ -15,7 +17,20 @@ var (
 	QueryTimeoutDuration = 5 * time.Second
 )
 
+// DBTX is the part of *sql.DB and *sql.Tx the stores use, so that every store
+// can run either directly on the pool or inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type Storage struct {
+	// db is nil for a Storage bound to a transaction.
+	db       *sql.DB
+	blobs    blob.Store
+	observer QueryObserver
+
 	FLTrainings interface {
 		GetAll(context.Context) ([]FLTraining, error)
 		GetByFLTrainingID(context.Context, string) (FLTraining, error)
@@ -27,6 +42,8 @@ type Storage struct {
 
 	FLTrainingClients interface {
 		GetAll(context.Context) ([]FLTrainingClient, error)
+		GetByFLTrainingID(ctx context.Context, flTrainingID string) ([]FLTrainingClient, error)
+		GetByID(context.Context, uuid.UUID) (FLTrainingClient, error)
 		Create(context.Context, FLTrainingClient) error
 		UpdateState(ctx context.Context, flTrainingID string, partitionID int, state string) error
 		GetByFLTrainingIDAndPartitionID(ctx context.Context, flTrainingID string, partitionID int) (FLTrainingClient, error)
@@ -39,6 +56,7 @@ type Storage struct {
 			state string,
 		) (FLTrainingClient, error)
 		UpdateLastLogRead(ctx context.Context, flTrainingID string, partitionID int, t time.Time) error
+		CountByState(context.Context) ([]ClientStateCount, error)
 	}
 
 	TrainingServers interface {
@@ -48,7 +66,9 @@ type Storage struct {
 
 	ClientLogs interface {
 		Create(context.Context, ClientLog) error
+		CreateBatch(context.Context, []ClientLog) error
 		GetByClientID(context.Context, uuid.UUID) ([]ClientLog, error)
+		Query(context.Context, ClientLogQuery) ([]ClientLog, error)
 	}
 
 	TrainingGraphs interface {
@@ -57,6 +77,8 @@ type Storage struct {
 		GetGraphIDsByClientID(context.Context, uuid.UUID) ([]uuid.UUID, error)
 		GetGraphsByClientID(context.Context, uuid.UUID) ([]TrainingGraph, error)
 		GetPointsByGraphID(context.Context, uuid.UUID) ([]TrainingGraphPoint, error)
+		GetExportRowsByFLTrainingID(context.Context, string) ([]TrainingGraphExportRow, error)
+		GetLatestPoints(context.Context) ([]LatestTrainingPoint, error)
 	}
 
 	TestingGraphs interface {
@@ -65,21 +87,89 @@ type Storage struct {
 		GetGraphIDsByClientID(context.Context, uuid.UUID) ([]uuid.UUID, error)
 		GetGraphsByClientID(context.Context, uuid.UUID) ([]TestingGraph, error)
 		GetPointsByGraphID(context.Context, uuid.UUID) ([]TestingGraphPoint, error)
+		GetExportRowsByFLTrainingID(context.Context, string) ([]TestingGraphExportRow, error)
+		GetLatestRoundResults(context.Context) ([]RoundTestResult, error)
 	}
 
 	FLModelWeights interface {
-		Upsert(ctx context.Context, flTrainingID string, serverRound int, payload []byte) error
+		Upsert(ctx context.Context, flTrainingID string, serverRound int, layers []weights.Layer) (FLModelWeights, error)
+		ListByFLTrainingID(ctx context.Context, flTrainingID string) ([]FLModelWeights, error)
+		GetByRound(ctx context.Context, flTrainingID string, serverRound int) (FLModelWeights, error)
+		Load(ctx context.Context, w FLModelWeights) ([]weights.Layer, error)
+	}
+
+	LogPullerCursors interface {
+		Get(ctx context.Context, podUID string, container string) (LogPullerCursor, error)
+		Upsert(context.Context, LogPullerCursor) error
+		DeleteByPodUID(ctx context.Context, podUID string) error
+	}
+
+	DeadLetterEvents interface {
+		Create(context.Context, DeadLetterEvent) error
+		GetByID(context.Context, uuid.UUID) (DeadLetterEvent, error)
+		List(context.Context, DeadLetterFilter) ([]DeadLetterEvent, error)
+		MarkResolved(context.Context, uuid.UUID) error
+		RecordFailedAttempt(ctx context.Context, id uuid.UUID, errMsg string) error
 	}
 }
 
-func NewStorage(db *sql.DB) *Storage {
+// NewStorage returns the stores backed by db. Model weights are kept in blobs.
+// If observer is not nil it is told about every query.
+func NewStorage(db *sql.DB, blobs blob.Store, observer QueryObserver) *Storage {
+	s := newStorage(db, blobs, observer)
+	s.db = db
+	return s
+}
+
+func newStorage(db DBTX, blobs blob.Store, observer QueryObserver) *Storage {
+	if observer != nil {
+		db = observedDB{db: db, observer: observer}
+	}
+
 	return &Storage{
+		blobs:             blobs,
+		observer:          observer,
 		FLTrainings:       NewFLTrainingStore(db),
 		FLTrainingClients: NewFLTrainingClientStore(db),
 		TrainingServers:   NewFLTrainingServerStore(db),
 		ClientLogs:        NewClientLogStore(db),
 		TrainingGraphs:    NewTrainingGraphStore(db),
 		TestingGraphs:     NewTestingGraphStore(db),
-		FLModelWeights:    NewFLModelWeightsStore(db),
+		FLModelWeights:    NewFLModelWeightsStore(db, blobs),
+		LogPullerCursors:  NewLogPullerCursorStore(db),
+		DeadLetterEvents:  NewDeadLetterEventStore(db),
+	}
+}
+
+// Ping checks that the database is reachable. A Storage bound to a transaction cannot ping.
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("storage is bound to a transaction")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.PingContext(ctx)
+}
+
+// WithTx runs fn with a Storage whose stores all share one transaction. The transaction
+// is committed if fn returns nil and rolled back otherwise. Called on a Storage that is
+// already bound to a transaction, fn joins that transaction.
+func (s *Storage) WithTx(ctx context.Context, fn func(*Storage) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+
+	if err := fn(newStorage(tx, s.blobs, s.observer)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,23 +23,27 @@ type TestingGraphPoint struct {
 	BatchSize   int       `json:"batch_size"`
 	TestLoss    float64   `json:"test_loss"`
 	Accuracy    float64   `json:"accuracy"`
+	EventAt     time.Time `json:"event_at"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 type TestingGraphStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewTestingGraphStore(db *sql.DB) *TestingGraphStore {
+func NewTestingGraphStore(db DBTX) *TestingGraphStore {
 	return &TestingGraphStore{db: db}
 }
 
+// Create inserts the testing graph of a client. A client has a single testing graph,
+// so creating it again is a no-op.
 func (s *TestingGraphStore) Create(ctx context.Context, g TestingGraph) error {
 	query := `
 		INSERT INTO testing_graphs (
 			client_id
 		)
 		VALUES ($1)
+		ON CONFLICT (client_id) DO NOTHING
 		RETURNING id, created_at
 	`
 
@@ -53,13 +58,17 @@ func (s *TestingGraphStore) Create(ctx context.Context, g TestingGraph) error {
 		&g.ID,
 		&g.CreatedAt,
 	)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// no row is returned when the graph already exists
 		return err
 	}
 
 	return nil
 }
 
+// CreatePoint inserts the point of (graph_id, server_round), or overwrites it if the existing
+// row was written by an event that is not newer (last writer wins by EventAt).
+// Replaying the same event is therefore a no-op.
 func (s *TestingGraphStore) CreatePoint(ctx context.Context, p TestingGraphPoint) error {
 	query := `
 		INSERT INTO testing_graph_points (
@@ -68,9 +77,18 @@ func (s *TestingGraphStore) CreatePoint(ctx context.Context, p TestingGraphPoint
 			criterion,
 			batch_size,
 			test_loss,
-			accuracy
+			accuracy,
+			event_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (graph_id, server_round)
+		DO UPDATE SET
+			criterion = EXCLUDED.criterion,
+			batch_size = EXCLUDED.batch_size,
+			test_loss = EXCLUDED.test_loss,
+			accuracy = EXCLUDED.accuracy,
+			event_at = EXCLUDED.event_at
+		WHERE testing_graph_points.event_at <= EXCLUDED.event_at
 		RETURNING id, created_at
 	`
 
@@ -86,11 +104,13 @@ func (s *TestingGraphStore) CreatePoint(ctx context.Context, p TestingGraphPoint
 		p.BatchSize,
 		p.TestLoss,
 		p.Accuracy,
+		p.EventAt,
 	).Scan(
 		&p.ID,
 		&p.CreatedAt,
 	)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// no row is returned when a newer event already wrote the point
 		return err
 	}
 
@@ -185,6 +205,7 @@ func (s *TestingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uuid
 			batch_size,
 			test_loss,
 			accuracy,
+			event_at,
 			created_at
 		FROM testing_graph_points
 		WHERE graph_id = $1
@@ -212,6 +233,7 @@ func (s *TestingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uuid
 			&p.BatchSize,
 			&p.TestLoss,
 			&p.Accuracy,
+			&p.EventAt,
 			&p.CreatedAt,
 		)
 		if err != nil {
@@ -227,3 +249,129 @@ func (s *TestingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uuid
 
 	return points, nil
 }
+
+// TestingGraphExportRow is a server-round point joined with its client.
+type TestingGraphExportRow struct {
+	FLTrainingID string    `json:"fl_training_id"`
+	PartitionID  int       `json:"partition_id"`
+	ServerRound  int       `json:"server_round"`
+	Criterion    string    `json:"criterion"`
+	BatchSize    int       `json:"batch_size"`
+	TestLoss     *float64  `json:"test_loss"` // nil if the client did not report it
+	Accuracy     *float64  `json:"accuracy"`
+	EventAt      time.Time `json:"event_at"`
+}
+
+// GetExportRowsByFLTrainingID returns every testing point of a training,
+// ordered by partition and server round.
+func (s *TestingGraphStore) GetExportRowsByFLTrainingID(ctx context.Context, flTrainingID string) ([]TestingGraphExportRow, error) {
+	query := `
+		SELECT
+			c.fl_training_id,
+			c.partition_id,
+			p.server_round,
+			COALESCE(p.criterion, ''),
+			COALESCE(p.batch_size, 0),
+			p.test_loss,
+			p.accuracy,
+			p.event_at
+		FROM testing_graph_points p
+		JOIN testing_graphs g ON g.id = p.graph_id
+		JOIN training_clients c ON c.id = g.client_id
+		WHERE c.fl_training_id = $1
+		ORDER BY c.partition_id ASC, p.server_round ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, flTrainingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []TestingGraphExportRow
+
+	for rows.Next() {
+		var r TestingGraphExportRow
+		err := rows.Scan(
+			&r.FLTrainingID,
+			&r.PartitionID,
+			&r.ServerRound,
+			&r.Criterion,
+			&r.BatchSize,
+			&r.TestLoss,
+			&r.Accuracy,
+			&r.EventAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RoundTestResult is the mean test result of the clients of a training in one server round.
+// Accuracy and TestLoss are nil if no client reported them.
+type RoundTestResult struct {
+	FLTrainingID string   `json:"fl_training_id"`
+	ServerRound  int      `json:"server_round"`
+	Clients      int      `json:"clients"`
+	Accuracy     *float64 `json:"accuracy"`
+	TestLoss     *float64 `json:"test_loss"`
+}
+
+// GetLatestRoundResults returns the mean test result of the newest round of every training.
+// Only the current and the previous server round of a training are looked at, so the query
+// does not grow with the history of the training.
+func (s *TestingGraphStore) GetLatestRoundResults(ctx context.Context) ([]RoundTestResult, error) {
+	query := `
+		SELECT DISTINCT ON (c.fl_training_id)
+			c.fl_training_id,
+			p.server_round,
+			COUNT(*),
+			AVG(p.accuracy),
+			AVG(p.test_loss)
+		FROM testing_graph_points p
+		JOIN testing_graphs g ON g.id = p.graph_id
+		JOIN training_clients c ON c.id = g.client_id
+		JOIN fl_trainings t ON t.fl_training_id = c.fl_training_id
+		WHERE p.server_round BETWEEN t.current_server_round - 1 AND t.current_server_round
+		GROUP BY c.fl_training_id, p.server_round
+		ORDER BY c.fl_training_id, p.server_round DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []RoundTestResult
+
+	for rows.Next() {
+		var r RoundTestResult
+		if err := rows.Scan(&r.FLTrainingID, &r.ServerRound, &r.Clients, &r.Accuracy, &r.TestLoss); err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,7 @@ type TrainingGraph struct {
 	LearningRate float64   `json:"learning_rate"`
 	NumEpochs    int       `json:"num_epochs"`
 	BatchSize    int       `json:"batch_size"`
+	EventAt      time.Time `json:"event_at"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -28,17 +30,21 @@ type TrainingGraphPoint struct {
 	ValLoss          float64   `json:"val_loss"`
 	Accuracy         float64   `json:"accuracy"`
 	EpochElapsedTime float64   `json:"epoch_elapsed_time"`
+	EventAt          time.Time `json:"event_at"`
 	CreatedAt        time.Time `json:"created_at"`
 }
 
 type TrainingGraphStore struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewTrainingGraphStore(db *sql.DB) *TrainingGraphStore {
+func NewTrainingGraphStore(db DBTX) *TrainingGraphStore {
 	return &TrainingGraphStore{db: db}
 }
 
+// Create inserts the graph of (client_id, server_round), or overwrites its configuration
+// if the existing row was written by an event that is not newer (last writer wins by EventAt).
+// A graph with a zero EventAt is a placeholder that never overwrites an existing row.
 func (s *TrainingGraphStore) Create(ctx context.Context, g TrainingGraph) error {
 	query := `
 		INSERT INTO training_graphs (
@@ -47,9 +53,18 @@ func (s *TrainingGraphStore) Create(ctx context.Context, g TrainingGraph) error
 			optimizer,
 			learning_rate,
 			num_epochs,
-			batch_size
+			batch_size,
+			event_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (client_id, server_round)
+		DO UPDATE SET
+			optimizer = EXCLUDED.optimizer,
+			learning_rate = EXCLUDED.learning_rate,
+			num_epochs = EXCLUDED.num_epochs,
+			batch_size = EXCLUDED.batch_size,
+			event_at = EXCLUDED.event_at
+		WHERE training_graphs.event_at <= EXCLUDED.event_at
 		RETURNING id, created_at
 	`
 
@@ -65,17 +80,22 @@ func (s *TrainingGraphStore) Create(ctx context.Context, g TrainingGraph) error
 		g.LearningRate,
 		g.NumEpochs,
 		g.BatchSize,
+		g.EventAt,
 	).Scan(
 		&g.ID,
 		&g.CreatedAt,
 	)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// no row is returned when a newer event already wrote the graph
 		return err
 	}
 
 	return nil
 }
 
+// CreatePoint inserts the point of (graph_id, current_epoch), or overwrites it if the existing
+// row was written by an event that is not newer (last writer wins by EventAt).
+// Replaying the same event is therefore a no-op.
 func (s *TrainingGraphStore) CreatePoint(ctx context.Context, p TrainingGraphPoint) error {
 	query := `
 		INSERT INTO training_graph_points (
@@ -85,9 +105,19 @@ func (s *TrainingGraphStore) CreatePoint(ctx context.Context, p TrainingGraphPoi
 			train_loss,
 			val_loss,
 			accuracy,
-			epoch_elapsed_time
+			epoch_elapsed_time,
+			event_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (graph_id, current_epoch)
+		DO UPDATE SET
+			trained_batch = EXCLUDED.trained_batch,
+			train_loss = EXCLUDED.train_loss,
+			val_loss = EXCLUDED.val_loss,
+			accuracy = EXCLUDED.accuracy,
+			epoch_elapsed_time = EXCLUDED.epoch_elapsed_time,
+			event_at = EXCLUDED.event_at
+		WHERE training_graph_points.event_at <= EXCLUDED.event_at
 		RETURNING id, created_at
 	`
 
@@ -104,11 +134,13 @@ func (s *TrainingGraphStore) CreatePoint(ctx context.Context, p TrainingGraphPoi
 		p.ValLoss,
 		p.Accuracy,
 		p.EpochElapsedTime,
+		p.EventAt,
 	).Scan(
 		&p.ID,
 		&p.CreatedAt,
 	)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// no row is returned when a newer event already wrote the point
 		return err
 	}
 
@@ -160,6 +192,7 @@ func (s *TrainingGraphStore) GetGraphsByClientID(ctx context.Context, clientID u
 			learning_rate,
 			num_epochs,
 			batch_size,
+			event_at,
 			created_at
 		FROM training_graphs
 		WHERE client_id = $1
@@ -187,6 +220,7 @@ func (s *TrainingGraphStore) GetGraphsByClientID(ctx context.Context, clientID u
 			&g.LearningRate,
 			&g.NumEpochs,
 			&g.BatchSize,
+			&g.EventAt,
 			&g.CreatedAt,
 		)
 		if err != nil {
@@ -214,6 +248,7 @@ func (s *TrainingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uui
 			val_loss,
 			accuracy,
 			epoch_elapsed_time,
+			event_at,
 			created_at
 		FROM training_graph_points
 		WHERE graph_id = $1
@@ -242,6 +277,7 @@ func (s *TrainingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uui
 			&p.ValLoss,
 			&p.Accuracy,
 			&p.EpochElapsedTime,
+			&p.EventAt,
 			&p.CreatedAt,
 		)
 		if err != nil {
@@ -257,3 +293,161 @@ func (s *TrainingGraphStore) GetPointsByGraphID(ctx context.Context, graphID uui
 
 	return points, nil
 }
+
+// TrainingGraphExportRow is an epoch point joined with its client and graph configuration.
+type TrainingGraphExportRow struct {
+	FLTrainingID     string    `json:"fl_training_id"`
+	PartitionID      int       `json:"partition_id"`
+	ServerRound      int       `json:"server_round"`
+	Optimizer        string    `json:"optimizer"`
+	LearningRate     float64   `json:"learning_rate"`
+	NumEpochs        int       `json:"num_epochs"`
+	BatchSize        int       `json:"batch_size"`
+	CurrentEpoch     int       `json:"current_epoch"`
+	TrainedBatch     int       `json:"trained_batch"`
+	TrainLoss        *float64  `json:"train_loss"` // nil if the client did not report it
+	ValLoss          *float64  `json:"val_loss"`
+	Accuracy         *float64  `json:"accuracy"`
+	EpochElapsedTime *float64  `json:"epoch_elapsed_time"`
+	EventAt          time.Time `json:"event_at"`
+}
+
+// GetExportRowsByFLTrainingID returns every epoch point of a training,
+// ordered by partition, server round and epoch.
+func (s *TrainingGraphStore) GetExportRowsByFLTrainingID(ctx context.Context, flTrainingID string) ([]TrainingGraphExportRow, error) {
+	query := `
+		SELECT
+			c.fl_training_id,
+			c.partition_id,
+			g.server_round,
+			COALESCE(g.optimizer, ''),
+			COALESCE(g.learning_rate, 0),
+			g.num_epochs,
+			g.batch_size,
+			p.current_epoch,
+			p.trained_batch,
+			p.train_loss,
+			p.val_loss,
+			p.accuracy,
+			p.epoch_elapsed_time,
+			p.event_at
+		FROM training_graph_points p
+		JOIN training_graphs g ON g.id = p.graph_id
+		JOIN training_clients c ON c.id = g.client_id
+		WHERE c.fl_training_id = $1
+		ORDER BY c.partition_id ASC, g.server_round ASC, p.current_epoch ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, flTrainingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []TrainingGraphExportRow
+
+	for rows.Next() {
+		var r TrainingGraphExportRow
+		err := rows.Scan(
+			&r.FLTrainingID,
+			&r.PartitionID,
+			&r.ServerRound,
+			&r.Optimizer,
+			&r.LearningRate,
+			&r.NumEpochs,
+			&r.BatchSize,
+			&r.CurrentEpoch,
+			&r.TrainedBatch,
+			&r.TrainLoss,
+			&r.ValLoss,
+			&r.Accuracy,
+			&r.EpochElapsedTime,
+			&r.EventAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LatestTrainingPoint is the newest epoch point of a client: the last epoch of its latest server round.
+// Metrics the client did not report are nil.
+type LatestTrainingPoint struct {
+	FLTrainingID string    `json:"fl_training_id"`
+	PartitionID  int       `json:"partition_id"`
+	ServerRound  int       `json:"server_round"`
+	CurrentEpoch int       `json:"current_epoch"`
+	TrainLoss    *float64  `json:"train_loss"`
+	ValLoss      *float64  `json:"val_loss"`
+	Accuracy     *float64  `json:"accuracy"`
+	EventAt      time.Time `json:"event_at"`
+}
+
+// GetLatestPoints returns the newest epoch point of every client that has one in the current
+// or the previous server round of its training; older rounds are not scanned.
+func (s *TrainingGraphStore) GetLatestPoints(ctx context.Context) ([]LatestTrainingPoint, error) {
+	query := `
+		SELECT DISTINCT ON (g.client_id)
+			c.fl_training_id,
+			c.partition_id,
+			g.server_round,
+			p.current_epoch,
+			p.train_loss,
+			p.val_loss,
+			p.accuracy,
+			p.event_at
+		FROM training_graph_points p
+		JOIN training_graphs g ON g.id = p.graph_id
+		JOIN training_clients c ON c.id = g.client_id
+		JOIN fl_trainings t ON t.fl_training_id = c.fl_training_id
+		WHERE g.server_round BETWEEN t.current_server_round - 1 AND t.current_server_round
+		ORDER BY g.client_id, g.server_round DESC, p.current_epoch DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []LatestTrainingPoint
+
+	for rows.Next() {
+		var p LatestTrainingPoint
+		err := rows.Scan(
+			&p.FLTrainingID,
+			&p.PartitionID,
+			&p.ServerRound,
+			&p.CurrentEpoch,
+			&p.TrainLoss,
+			&p.ValLoss,
+			&p.Accuracy,
+			&p.EventAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
@@ -0,0 +1,105 @@
+package weights
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// LayerStats summarizes the values of one layer.
+type LayerStats struct {
+	Name   string  `json:"name,omitempty"`
+	Shape  []int   `json:"shape"`
+	Count  int     `json:"count"`
+	L2Norm float64 `json:"l2_norm"`
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"` // population standard deviation
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// Stats returns the statistics of every layer. A layer without values has all-zero statistics.
+func Stats(layers []Layer) []LayerStats {
+	stats := make([]LayerStats, len(layers))
+
+	for i, l := range layers {
+		s := LayerStats{Name: l.Name, Shape: l.Shape, Count: len(l.Values)}
+		if s.Count == 0 {
+			stats[i] = s
+			continue
+		}
+
+		var sum, sumSq float64
+		s.Min = math.Inf(1)
+		s.Max = math.Inf(-1)
+		for _, x := range l.Values {
+			sum += x
+			sumSq += x * x
+			s.Min = min(s.Min, x)
+			s.Max = max(s.Max, x)
+		}
+
+		n := float64(s.Count)
+		s.L2Norm = math.Sqrt(sumSq)
+		s.Mean = sum / n
+		// clamp the rounding error of E[x²] - E[x]² for near-constant layers
+		s.Std = math.Sqrt(max(sumSq/n-s.Mean*s.Mean, 0))
+
+		stats[i] = s
+	}
+
+	return stats
+}
+
+// LayerDiff compares one layer of two rounds.
+type LayerDiff struct {
+	Name  string `json:"name,omitempty"`
+	Shape []int  `json:"shape"`
+	// UpdateNorm is the L2 norm of to - from.
+	UpdateNorm float64 `json:"update_norm"`
+	// RelativeUpdateNorm is UpdateNorm divided by the L2 norm of from; nil if from is all zeros.
+	RelativeUpdateNorm *float64 `json:"relative_update_norm"`
+	// CosineSimilarity is the cosine of the angle between from and to; nil if either is all zeros.
+	CosineSimilarity *float64 `json:"cosine_similarity"`
+}
+
+// Diff compares every layer of from with the same layer of to.
+// Both must have the same number of layers with the same shapes.
+func Diff(from, to []Layer) ([]LayerDiff, error) {
+	if len(from) != len(to) {
+		return nil, fmt.Errorf("layer count differs: %d and %d", len(from), len(to))
+	}
+
+	diffs := make([]LayerDiff, len(from))
+
+	for i := range from {
+		a, b := from[i], to[i]
+		if !slices.Equal(a.Shape, b.Shape) {
+			return nil, fmt.Errorf("layer %d: shapes %v and %v differ", i, a.Shape, b.Shape)
+		}
+
+		var dot, normA, normB, update float64
+		for j := range a.Values {
+			x, y := a.Values[j], b.Values[j]
+			dot += x * y
+			normA += x * x
+			normB += y * y
+			update += (y - x) * (y - x)
+		}
+		normA, normB = math.Sqrt(normA), math.Sqrt(normB)
+
+		d := LayerDiff{Name: b.Name, Shape: b.Shape, UpdateNorm: math.Sqrt(update)}
+		if normA > 0 {
+			rel := d.UpdateNorm / normA
+			d.RelativeUpdateNorm = &rel
+			if normB > 0 {
+				cos := max(-1, min(dot/(normA*normB), 1))
+				d.CosineSimilarity = &cos
+			}
+		}
+
+		diffs[i] = d
+	}
+
+	return diffs, nil
+}
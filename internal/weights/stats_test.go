@@ -0,0 +1,151 @@
+package weights
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// tolerance absorbs the rounding of sums of inputs such as 0.1.
+const tolerance = 1e-12
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer Layer
+		want  LayerStats
+	}{
+		{
+			name:  "3-4-5",
+			layer: Layer{Name: "w", Shape: []int{2}, Values: []float64{3, 4}},
+			want:  LayerStats{Name: "w", Count: 2, L2Norm: 5, Mean: 3.5, Std: 0.5, Min: 3, Max: 4},
+		},
+		{
+			name:  "constant layer",
+			layer: Layer{Shape: []int{2, 2}, Values: []float64{0.1, 0.1, 0.1, 0.1}},
+			want:  LayerStats{Count: 4, L2Norm: 0.2, Mean: 0.1, Std: 0, Min: 0.1, Max: 0.1},
+		},
+		{
+			name:  "negative values",
+			layer: Layer{Shape: []int{4}, Values: []float64{-2, -1, 1, 2}},
+			want:  LayerStats{Count: 4, L2Norm: math.Sqrt(10), Mean: 0, Std: math.Sqrt(2.5), Min: -2, Max: 2},
+		},
+		{
+			name:  "empty layer",
+			layer: Layer{Shape: []int{0}},
+			want:  LayerStats{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Stats([]Layer{tt.layer})[0]
+
+			if got.Name != tt.want.Name || got.Count != tt.want.Count ||
+				!approxEqual(got.L2Norm, tt.want.L2Norm) || !approxEqual(got.Mean, tt.want.Mean) ||
+				!approxEqual(got.Std, tt.want.Std) || !approxEqual(got.Min, tt.want.Min) ||
+				!approxEqual(got.Max, tt.want.Max) {
+				t.Fatalf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		from, to []float64
+		want     LayerDiff
+	}{
+		{
+			name: "scaled",
+			from: []float64{3, 4},
+			to:   []float64{6, 8},
+			want: LayerDiff{UpdateNorm: 5, RelativeUpdateNorm: ptr(1), CosineSimilarity: ptr(1)},
+		},
+		{
+			name: "orthogonal",
+			from: []float64{1, 0},
+			to:   []float64{0, 1},
+			want: LayerDiff{UpdateNorm: math.Sqrt2, RelativeUpdateNorm: ptr(math.Sqrt2), CosineSimilarity: ptr(0)},
+		},
+		{
+			name: "opposite",
+			from: []float64{1, 0},
+			to:   []float64{-2, 0},
+			want: LayerDiff{UpdateNorm: 3, RelativeUpdateNorm: ptr(3), CosineSimilarity: ptr(-1)},
+		},
+		{
+			name: "unchanged",
+			from: []float64{1, 2},
+			to:   []float64{1, 2},
+			want: LayerDiff{UpdateNorm: 0, RelativeUpdateNorm: ptr(0), CosineSimilarity: ptr(1)},
+		},
+		{
+			name: "from all zeros",
+			from: []float64{0, 0},
+			to:   []float64{1, 1},
+			want: LayerDiff{UpdateNorm: math.Sqrt2},
+		},
+		{
+			name: "to all zeros",
+			from: []float64{1, 0},
+			to:   []float64{0, 0},
+			want: LayerDiff{UpdateNorm: 1, RelativeUpdateNorm: ptr(1)},
+		},
+	}
+
+	optEqual := func(a, b *float64) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return approxEqual(*a, *b)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := []Layer{{Name: "w", Shape: []int{len(tt.from)}, Values: tt.from}}
+			to := []Layer{{Name: "w", Shape: []int{len(tt.to)}, Values: tt.to}}
+
+			diffs, err := Diff(from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := diffs[0]
+
+			if got.Name != "w" || !approxEqual(got.UpdateNorm, tt.want.UpdateNorm) ||
+				!optEqual(got.RelativeUpdateNorm, tt.want.RelativeUpdateNorm) ||
+				!optEqual(got.CosineSimilarity, tt.want.CosineSimilarity) {
+				t.Fatalf("Diff() = %s, want %s", formatDiff(got), formatDiff(tt.want))
+			}
+		})
+	}
+}
+
+func TestDiffMismatch(t *testing.T) {
+	a := []Layer{{Shape: []int{2}, Values: []float64{1, 2}}}
+	b := []Layer{{Shape: []int{1, 2}, Values: []float64{1, 2}}}
+
+	if _, err := Diff(a, append(a, a...)); err == nil {
+		t.Fatal("Diff() of different layer counts succeeded, want error")
+	}
+	if _, err := Diff(a, b); err == nil {
+		t.Fatal("Diff() of different shapes succeeded, want error")
+	}
+}
+
+func formatDiff(d LayerDiff) string {
+	opt := func(v *float64) any {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return fmt.Sprintf("{update %v, relative %v, cosine %v}", d.UpdateNorm, opt(d.RelativeUpdateNorm), opt(d.CosineSimilarity))
+}
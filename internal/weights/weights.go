@@ -0,0 +1,236 @@
+// Package weights converts model weights between their JSON log form and a compact binary form.
+package weights
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// Element types of the binary form. Clients usually train in float32, whose values the JSON
+// numbers print exactly, so such models are stored as float32; any other model is stored
+// as float64 so that no value is rounded.
+const (
+	DTypeFloat32 = "float32"
+	DTypeFloat64 = "float64"
+)
+
+// Layer is one named tensor of a model. Name is empty if the layers were sent as a list.
+type Layer struct {
+	Name   string
+	Shape  []int
+	Values []float64
+}
+
+// LayerMeta describes a layer without its values.
+type LayerMeta struct {
+	Name  string `json:"name,omitempty"`
+	Shape []int  `json:"shape"`
+}
+
+// Meta returns the name and shape of every layer.
+func Meta(layers []Layer) []LayerMeta {
+	meta := make([]LayerMeta, len(layers))
+	for i, l := range layers {
+		meta[i] = LayerMeta{Name: l.Name, Shape: l.Shape}
+	}
+	return meta
+}
+
+// NumElements returns the number of values of a tensor with the given shape.
+func NumElements(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+// ParseJSON reads the layers of a MODEL_WEIGHTS payload. They are either a list of
+// (nested) number arrays, one per layer, or an object mapping layer names to such arrays,
+// as produced by numpy's tolist(). Every array must be rectangular.
+func ParseJSON(raw json.RawMessage) ([]Layer, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("read layers: %w", err)
+	}
+
+	p := parser{dec: dec}
+	var layers []Layer
+
+	switch tok {
+	case json.Delim('['):
+		for dec.More() {
+			l, err := p.layer("")
+			if err != nil {
+				return nil, fmt.Errorf("layer %d: %w", len(layers), err)
+			}
+			layers = append(layers, l)
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, _ := key.(string)
+
+			l, err := p.layer(name)
+			if err != nil {
+				return nil, fmt.Errorf("layer %q: %w", name, err)
+			}
+			layers = append(layers, l)
+		}
+	default:
+		return nil, errors.New("layers must be a JSON array or object")
+	}
+
+	if len(layers) == 0 {
+		return nil, errors.New("no layers")
+	}
+
+	return layers, nil
+}
+
+type parser struct {
+	dec    *json.Decoder
+	values []float64
+}
+
+func (p *parser) layer(name string) (Layer, error) {
+	p.values = nil
+
+	shape, err := p.value()
+	if err != nil {
+		return Layer{}, err
+	}
+
+	return Layer{Name: name, Shape: shape, Values: p.values}, nil
+}
+
+// value reads a number or a nested array of numbers, appends the numbers to p.values
+// and returns the shape of the value.
+func (p *parser) value() ([]int, error) {
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case float64:
+		p.values = append(p.values, t)
+		return []int{}, nil
+	case json.Delim:
+		if t != '[' {
+			return nil, fmt.Errorf("unexpected %v", t)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected %v, expected a number or an array", t)
+	}
+
+	var inner []int
+	n := 0
+	for p.dec.More() {
+		shape, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 && !slices.Equal(shape, inner) {
+			return nil, fmt.Errorf("ragged array: shapes %v and %v", inner, shape)
+		}
+		inner = shape
+		n++
+	}
+
+	if _, err := p.dec.Token(); err != nil { // closing ]
+		return nil, err
+	}
+
+	return append([]int{n}, inner...), nil
+}
+
+// DTypeOf returns float32 if float32 holds every value of layers exactly, and float64 otherwise.
+func DTypeOf(layers []Layer) string {
+	for _, l := range layers {
+		for _, v := range l.Values {
+			if float64(float32(v)) != v && !math.IsNaN(v) {
+				return DTypeFloat64
+			}
+		}
+	}
+	return DTypeFloat32
+}
+
+// dtypeSize returns the size in bytes of one value of dtype.
+func dtypeSize(dtype string) (int, error) {
+	switch dtype {
+	case DTypeFloat32:
+		return 4, nil
+	case DTypeFloat64:
+		return 8, nil
+	default:
+		return 0, fmt.Errorf("unknown dtype %q", dtype)
+	}
+}
+
+// Encode writes the values of every layer as little-endian values of dtype, one layer after
+// the other. dtype should be DTypeOf(layers); float32 rounds values it cannot hold.
+func Encode(layers []Layer, dtype string) ([]byte, error) {
+	width, err := dtypeSize(dtype)
+	if err != nil {
+		return nil, err
+	}
+
+	size := 0
+	for _, l := range layers {
+		size += len(l.Values) * width
+	}
+
+	buf := make([]byte, 0, size)
+	for _, l := range layers {
+		for _, v := range l.Values {
+			if dtype == DTypeFloat64 {
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+			} else {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+			}
+		}
+	}
+	return buf, nil
+}
+
+// Decode reads layers written by Encode with dtype, splitting them by the shapes in meta.
+func Decode(r io.Reader, meta []LayerMeta, dtype string) ([]Layer, error) {
+	if _, err := dtypeSize(dtype); err != nil {
+		return nil, err
+	}
+
+	layers := make([]Layer, len(meta))
+
+	for i, m := range meta {
+		values := make([]float64, NumElements(m.Shape))
+		if dtype == DTypeFloat64 {
+			if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+				return nil, fmt.Errorf("read layer %d: %w", i, err)
+			}
+		} else {
+			narrow := make([]float32, len(values))
+			if err := binary.Read(r, binary.LittleEndian, narrow); err != nil {
+				return nil, fmt.Errorf("read layer %d: %w", i, err)
+			}
+			for j, v := range narrow {
+				values[j] = float64(v)
+			}
+		}
+		layers[i] = Layer{Name: m.Name, Shape: m.Shape, Values: values}
+	}
+
+	return layers, nil
+}
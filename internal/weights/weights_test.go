@@ -0,0 +1,159 @@
+package weights
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []Layer
+		wantErr bool
+	}{
+		{
+			name: "list of layers",
+			raw:  `[[1, 2, 3], [[1.5, 2], [3, 4]]]`,
+			want: []Layer{
+				{Shape: []int{3}, Values: []float64{1, 2, 3}},
+				{Shape: []int{2, 2}, Values: []float64{1.5, 2, 3, 4}},
+			},
+		},
+		{
+			name: "object of named layers keeps their order",
+			raw:  `{"w": [[1, 2], [3, 4], [5, 6]], "b": [0.5, -0.5]}`,
+			want: []Layer{
+				{Name: "w", Shape: []int{3, 2}, Values: []float64{1, 2, 3, 4, 5, 6}},
+				{Name: "b", Shape: []int{2}, Values: []float64{0.5, -0.5}},
+			},
+		},
+		{
+			name: "scalar layer",
+			raw:  `{"step": 7}`,
+			want: []Layer{{Name: "step", Shape: []int{}, Values: []float64{7}}},
+		},
+		{
+			name: "empty layer",
+			raw:  `[[]]`,
+			want: []Layer{{Shape: []int{0}}},
+		},
+		{name: "ragged inner arrays", raw: `[[[1, 2], [3]]]`, wantErr: true},
+		{name: "number next to an array", raw: `[[1, [2]]]`, wantErr: true},
+		{name: "no layers", raw: `[]`, wantErr: true},
+		{name: "neither list nor object", raw: `1`, wantErr: true},
+		{name: "string value", raw: `[["a"]]`, wantErr: true},
+		{name: "invalid JSON", raw: `[[1, 2`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONKeepsFloat64Values(t *testing.T) {
+	layers, err := ParseJSON(json.RawMessage(`[[0.1, 0.30000000000000004]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.1, 0.30000000000000004}; !reflect.DeepEqual(layers[0].Values, want) {
+		t.Fatalf("values = %v, want %v", layers[0].Values, want)
+	}
+}
+
+func TestDTypeOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   string
+	}{
+		{name: "float32 values", values: []float64{1, -2.5, float64(float32(0.1)), math.Inf(1), math.NaN()}, want: DTypeFloat32},
+		{name: "value float32 rounds", values: []float64{1, 0.1}, want: DTypeFloat64},
+		{name: "value beyond float32", values: []float64{1e300}, want: DTypeFloat64},
+		{name: "no values", want: DTypeFloat32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := []Layer{{Shape: []int{len(tt.values)}, Values: tt.values}}
+			if got := DTypeOf(layers); got != tt.want {
+				t.Fatalf("DTypeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		dtype  string
+		layers []Layer
+	}{
+		{
+			dtype: DTypeFloat32,
+			layers: []Layer{
+				{Name: "w", Shape: []int{2, 3}, Values: []float64{1, -2.5, float64(float32(3e-8)), 0, float64(float32(1e30)), -0.125}},
+				{Name: "b", Shape: []int{3}, Values: []float64{float64(float32(0.1)), float64(float32(0.2)), float64(float32(0.3))}},
+				{Name: "step", Shape: []int{}, Values: []float64{42}},
+			},
+		},
+		{
+			dtype: DTypeFloat64,
+			layers: []Layer{
+				{Name: "w", Shape: []int{2, 3}, Values: []float64{1, -2.5, 3e-8, 0, 1e300, -0.125}},
+				{Name: "b", Shape: []int{3}, Values: []float64{0.1, 0.2, 0.3}},
+				{Name: "step", Shape: []int{}, Values: []float64{42}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dtype, func(t *testing.T) {
+			if got := DTypeOf(tt.layers); got != tt.dtype {
+				t.Fatalf("DTypeOf() = %q, want %q", got, tt.dtype)
+			}
+
+			raw, err := Encode(tt.layers, tt.dtype)
+			if err != nil {
+				t.Fatal(err)
+			}
+			width, _ := dtypeSize(tt.dtype)
+			if want := 10 * width; len(raw) != want {
+				t.Fatalf("Encode() wrote %d bytes, want %d", len(raw), want)
+			}
+
+			got, err := Decode(bytes.NewReader(raw), Meta(tt.layers), tt.dtype)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.layers) {
+				t.Fatalf("Decode(Encode()) = %+v, want %+v", got, tt.layers)
+			}
+
+			// a blob shorter than its metadata is an error, not zero-filled layers
+			if _, err := Decode(bytes.NewReader(raw[:len(raw)-1]), Meta(tt.layers), tt.dtype); err == nil {
+				t.Fatal("Decode() of a short blob succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestUnknownDType(t *testing.T) {
+	layers := []Layer{{Shape: []int{1}, Values: []float64{1}}}
+	if _, err := Encode(layers, "int8"); err == nil {
+		t.Fatal("Encode() with an unknown dtype succeeded, want error")
+	}
+	if _, err := Decode(bytes.NewReader(nil), Meta(layers), "int8"); err == nil {
+		t.Fatal("Decode() with an unknown dtype succeeded, want error")
+	}
+}